@@ -0,0 +1,167 @@
+// Package console provides an exporter printing spans in a human-readable form,
+// meant for local development without a tracing backend
+package console
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+var statusCodeNames = map[int32]string{
+	trace.StatusCodeOK:                 "OK",
+	trace.StatusCodeCancelled:          "CANCELLED",
+	trace.StatusCodeUnknown:            "UNKNOWN",
+	trace.StatusCodeInvalidArgument:    "INVALID_ARGUMENT",
+	trace.StatusCodeDeadlineExceeded:   "DEADLINE_EXCEEDED",
+	trace.StatusCodeNotFound:           "NOT_FOUND",
+	trace.StatusCodeAlreadyExists:      "ALREADY_EXISTS",
+	trace.StatusCodePermissionDenied:   "PERMISSION_DENIED",
+	trace.StatusCodeResourceExhausted:  "RESOURCE_EXHAUSTED",
+	trace.StatusCodeFailedPrecondition: "FAILED_PRECONDITION",
+	trace.StatusCodeAborted:            "ABORTED",
+	trace.StatusCodeOutOfRange:         "OUT_OF_RANGE",
+	trace.StatusCodeUnimplemented:      "UNIMPLEMENTED",
+	trace.StatusCodeInternal:           "INTERNAL",
+	trace.StatusCodeUnavailable:        "UNAVAILABLE",
+	trace.StatusCodeDataLoss:           "DATA_LOSS",
+	trace.StatusCodeUnauthenticated:    "UNAUTHENTICATED",
+}
+
+// Exporter prints every exported span with its duration, status, attributes and events
+type Exporter struct {
+	mu    sync.Mutex
+	print func(s string)
+}
+
+// NewExporter returns the exporter printing spans to the writer, e.g. os.Stdout
+func NewExporter(w io.Writer) *Exporter {
+	return &Exporter{
+		print: func(s string) {
+			_, _ = io.WriteString(w, s)
+		},
+	}
+}
+
+// NewLoggerExporter returns the exporter printing every span as a single entry of the logger
+func NewLoggerExporter(logger *log.Logger) *Exporter {
+	return &Exporter{
+		print: func(s string) {
+			logger.Print(s)
+		},
+	}
+}
+
+// ExportSpan prints the span, it is safe for concurrent use
+func (e *Exporter) ExportSpan(sd *trace.SpanData) {
+	s := Format(sd)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.print(s)
+}
+
+// Format returns the human-readable form of the span printed by the exporter, e.g.
+//
+//	[GET] /users/{id} 1.52ms OK
+//	  trace_id: 4bf92f3577b34da6a3ce929d0e0e4736 span_id: 00f067aa0ba902b7
+//	  attributes:
+//	    http.method: GET
+//	  events:
+//	    +0.42ms Response started
+func Format(sd *trace.SpanData) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s %s\n", sd.Name, formatDuration(sd.EndTime.Sub(sd.StartTime)), formatStatus(sd.Status))
+	fmt.Fprintf(&b, "  trace_id: %s span_id: %s", sd.TraceID, sd.SpanID)
+	if sd.ParentSpanID != (trace.SpanID{}) {
+		fmt.Fprintf(&b, " parent_span_id: %s", sd.ParentSpanID)
+	}
+	b.WriteString("\n")
+
+	if len(sd.Attributes) > 0 {
+		b.WriteString("  attributes:\n")
+		for _, key := range sortedKeys(sd.Attributes) {
+			fmt.Fprintf(&b, "    %s: %v\n", key, sd.Attributes[key])
+		}
+	}
+
+	if len(sd.Annotations) > 0 || len(sd.MessageEvents) > 0 {
+		b.WriteString("  events:\n")
+		for _, e := range events(sd) {
+			fmt.Fprintf(&b, "    +%s %s\n", formatDuration(e.time.Sub(sd.StartTime)), e.description)
+		}
+	}
+
+	return b.String()
+}
+
+type event struct {
+	time        time.Time
+	description string
+}
+
+func events(sd *trace.SpanData) []event {
+	events := make([]event, 0, len(sd.Annotations)+len(sd.MessageEvents))
+	for _, a := range sd.Annotations {
+		description := a.Message
+		for _, key := range sortedKeys(a.Attributes) {
+			description += fmt.Sprintf(" %s=%v", key, a.Attributes[key])
+		}
+		events = append(events, event{a.Time, description})
+	}
+	for _, m := range sd.MessageEvents {
+		events = append(events, event{m.Time, fmt.Sprintf(
+			"%s message id=%d uncompressed_size=%d compressed_size=%d",
+			messageEventTypeName(m.EventType),
+			m.MessageID,
+			m.UncompressedByteSize,
+			m.CompressedByteSize,
+		)})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].time.Before(events[j].time)
+	})
+	return events
+}
+
+func messageEventTypeName(t trace.MessageEventType) string {
+	switch t {
+	case trace.MessageEventTypeSent:
+		return "sent"
+	case trace.MessageEventTypeRecv:
+		return "received"
+	default:
+		return "unspecified"
+	}
+}
+
+func formatStatus(status trace.Status) string {
+	name, ok := statusCodeNames[status.Code]
+	if !ok {
+		name = fmt.Sprintf("CODE(%d)", status.Code)
+	}
+	if status.Message == "" || status.Message == name {
+		return name
+	}
+	return name + " (" + status.Message + ")"
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
+
+func sortedKeys(attributes map[string]interface{}) []string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,52 @@
+package console
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+func TestExporter(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	buff := &bytes.Buffer{}
+	exporter := NewExporter(buff)
+	exporter.ExportSpan(&trace.SpanData{
+		SpanContext: trace.SpanContext{
+			TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		},
+		Name:      "[GET] /users/{id}",
+		StartTime: start,
+		EndTime:   start.Add(1500 * time.Microsecond),
+		Status:    trace.Status{Code: trace.StatusCodeNotFound, Message: "Not Found"},
+		Attributes: map[string]interface{}{
+			"http.status_code": int64(404),
+			"http.method":      "GET",
+		},
+		Annotations: []trace.Annotation{
+			{Time: start.Add(500 * time.Microsecond), Message: "Response started"},
+		},
+		MessageEvents: []trace.MessageEvent{
+			{Time: start.Add(1000 * time.Microsecond), EventType: trace.MessageEventTypeSent, MessageID: 1, UncompressedByteSize: 9},
+		},
+	})
+
+	expectedOutput := strings.Join([]string{
+		"[GET] /users/{id} 1.50ms NOT_FOUND (Not Found)",
+		"  trace_id: 4bf92f3577b34da6a3ce929d0e0e4736 span_id: 00f067aa0ba902b7",
+		"  attributes:",
+		"    http.method: GET",
+		"    http.status_code: 404",
+		"  events:",
+		"    +0.50ms Response started",
+		"    +1.00ms sent message id=1 uncompressed_size=9 compressed_size=0",
+		"",
+	}, "\n")
+	if buff.String() != expectedOutput {
+		t.Fatalf("Expected the span to be printed as:\n%s\nwhile it was printed as:\n%s", expectedOutput, buff.String())
+	}
+}
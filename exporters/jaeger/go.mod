@@ -0,0 +1,8 @@
+module github.com/krzysztofreczek/chi-opencensus-tracing/exporters/jaeger
+
+go 1.16
+
+require (
+	contrib.go.opencensus.io/exporter/jaeger v0.2.1
+	go.opencensus.io v0.23.0
+)
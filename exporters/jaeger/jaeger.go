@@ -0,0 +1,33 @@
+// Package jaeger bootstraps the opencensus Jaeger exporter for the spans of the tracing middleware.
+// It is a module of its own, so the dependencies of the exporter are not pulled by the middleware.
+package jaeger
+
+import (
+	ocjaeger "contrib.go.opencensus.io/exporter/jaeger"
+	"go.opencensus.io/trace"
+)
+
+// Setup registers the exporter sending the spans of the service to the Jaeger collector,
+// e.g. "http://localhost:14268/api/traces", and samples the given fraction of the traces, 1 to sample all of them.
+// The returned function unregisters the exporter and uploads the spans it still buffers, to be called on shutdown.
+func Setup(serviceName, collectorURL string, sampleRate float64) (flush func(), err error) {
+	exporter, err := ocjaeger.NewExporter(ocjaeger.Options{
+		CollectorEndpoint: collectorURL,
+		Process: ocjaeger.Process{
+			ServiceName: serviceName,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trace.RegisterExporter(exporter)
+	trace.ApplyConfig(trace.Config{
+		DefaultSampler: trace.ProbabilitySampler(sampleRate),
+	})
+
+	return func() {
+		trace.UnregisterExporter(exporter)
+		exporter.Flush()
+	}, nil
+}
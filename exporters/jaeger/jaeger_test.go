@@ -0,0 +1,39 @@
+package jaeger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestSetup(t *testing.T) {
+	uploads := 0
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+	}))
+	defer collector.Close()
+
+	flush, err := Setup("test", collector.URL, 1.0)
+	if err != nil {
+		t.Fatalf("Expected the exporter to be set up, while it failed with: %s", err)
+	}
+
+	_, span := trace.StartSpan(context.Background(), "span")
+	span.End()
+	flush()
+
+	expectedNumberOfUploads := 1
+	if uploads != expectedNumberOfUploads {
+		t.Fatalf("Expected the collector to receive %d upload(s), while it received %d", expectedNumberOfUploads, uploads)
+	}
+}
+
+func TestSetup_missing_collector(t *testing.T) {
+	_, err := Setup("test", "", 1.0)
+	if err == nil {
+		t.Fatalf("Expected the setup to fail without the collector URL")
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/krzysztofreczek/chi-opencensus-tracing/exporters/prometheus
+
+go 1.16
+
+require (
+	contrib.go.opencensus.io/exporter/prometheus v0.4.2
+	github.com/go-chi/chi/v5 v5.0.3
+	github.com/krzysztofreczek/chi-opencensus-tracing v0.0.0-00010101000000-000000000000
+	go.opencensus.io v0.23.0
+)
+
+replace github.com/krzysztofreczek/chi-opencensus-tracing => ../..
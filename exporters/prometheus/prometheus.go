@@ -0,0 +1,37 @@
+// Package prometheus exposes the HTTP server metrics recorded by the tracing middleware
+// in the Prometheus exposition format
+package prometheus
+
+import (
+	"net/http"
+
+	ocprometheus "contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/middleware"
+	"go.opencensus.io/stats/view"
+)
+
+const (
+	// MetricsPath is the conventional path of the metrics endpoint
+	MetricsPath = "/metrics"
+)
+
+// Handler registers the server views of the middleware, see middleware.DefaultServerViews,
+// and returns the handler serving them in the Prometheus exposition format
+func Handler(opts ocprometheus.Options) (http.Handler, error) {
+	if err := view.Register(middleware.DefaultServerViews()...); err != nil {
+		return nil, err
+	}
+	return ocprometheus.NewExporter(opts)
+}
+
+// Mount registers the server views of the middleware and serves them at MetricsPath of the router,
+// e.g. next to the routes traced with middleware.MetricsAndTracing
+func Mount(r chi.Router, opts ocprometheus.Options) error {
+	h, err := Handler(opts)
+	if err != nil {
+		return err
+	}
+	r.Method(http.MethodGet, MetricsPath, h)
+	return nil
+}
@@ -0,0 +1,40 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocprometheus "contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/middleware"
+	"go.opencensus.io/stats/view"
+)
+
+func TestMount(t *testing.T) {
+	r := chi.NewRouter()
+	if err := Mount(r, ocprometheus.Options{Namespace: "test"}); err != nil {
+		t.Fatalf("Expected the metrics handler to be mounted, while it failed with: %s", err)
+	}
+	defer view.Unregister(middleware.DefaultServerViews()...)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.MetricsAndTracing())
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+	})
+
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", MetricsPath, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedMetric := `test_opencensus_io_http_server_request_count_by_route{http_method="GET",http_server_route="/users/{id}",http_status="404"} 1`
+	if !strings.Contains(w.Body.String(), expectedMetric) {
+		t.Fatalf("Expected the metrics to contain '%s', while they were:\n%s", expectedMetric, w.Body.String())
+	}
+}
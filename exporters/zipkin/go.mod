@@ -0,0 +1,9 @@
+module github.com/krzysztofreczek/chi-opencensus-tracing/exporters/zipkin
+
+go 1.16
+
+require (
+	contrib.go.opencensus.io/exporter/zipkin v0.1.2
+	github.com/openzipkin/zipkin-go v0.4.1
+	go.opencensus.io v0.23.0
+)
@@ -0,0 +1,30 @@
+// Package zipkin bootstraps the opencensus Zipkin exporter for the spans of the tracing middleware,
+// to be paired with the B3 propagation of the middleware, see propagation.B3
+package zipkin
+
+import (
+	oczipkin "contrib.go.opencensus.io/exporter/zipkin"
+	"github.com/openzipkin/zipkin-go"
+	reporterhttp "github.com/openzipkin/zipkin-go/reporter/http"
+	"go.opencensus.io/trace"
+)
+
+// Setup registers the exporter reporting the spans of the service to the Zipkin collector,
+// e.g. "http://localhost:9411/api/v2/spans". The spans are attributed to the local endpoint of the service,
+// i.e. its name and the address it listens on, e.g. "10.0.0.1:8080", which may be left empty.
+// The returned function unregisters the exporter and reports the spans it still buffers, to be called on shutdown.
+func Setup(serviceName, hostPort, collectorURL string) (shutdown func() error, err error) {
+	endpoint, err := zipkin.NewEndpoint(serviceName, hostPort)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter := reporterhttp.NewReporter(collectorURL)
+	exporter := oczipkin.NewExporter(reporter, endpoint)
+	trace.RegisterExporter(exporter)
+
+	return func() error {
+		trace.UnregisterExporter(exporter)
+		return reporter.Close()
+	}, nil
+}
@@ -0,0 +1,48 @@
+package zipkin
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestSetup(t *testing.T) {
+	reported := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		reported <- string(body)
+	}))
+	defer collector.Close()
+
+	shutdown, err := Setup("test", "127.0.0.1:8080", collector.URL)
+	if err != nil {
+		t.Fatalf("Expected the exporter to be set up, while it failed with: %s", err)
+	}
+
+	_, span := trace.StartSpan(context.Background(), "span", trace.WithSampler(trace.AlwaysSample()))
+	span.End()
+
+	if err := shutdown(); err != nil {
+		t.Fatalf("Expected the exporter to be shut down, while it failed with: %s", err)
+	}
+
+	body := <-reported
+	expectedFragments := []string{`"name":"span"`, `"serviceName":"test"`}
+	for _, fragment := range expectedFragments {
+		if !strings.Contains(body, fragment) {
+			t.Fatalf("Expected the reported spans to contain '%s', while they were: %s", fragment, body)
+		}
+	}
+}
+
+func TestSetup_invalid_host_port(t *testing.T) {
+	_, err := Setup("test", "invalid:port", "http://localhost:9411/api/v2/spans")
+	if err == nil {
+		t.Fatalf("Expected the setup to fail for an invalid host and port")
+	}
+}
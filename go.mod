@@ -3,6 +3,15 @@ module github.com/krzysztofreczek/chi-opencensus-tracing
 go 1.16
 
 require (
-	github.com/go-chi/chi/v5 v5.0.3 // indirect
-	go.opencensus.io v0.23.0 // indirect
+	github.com/go-chi/chi/v5 v5.0.3
+	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
+	github.com/google/go-cmp v0.5.8 // indirect
+	github.com/stretchr/testify v1.8.0 // indirect
+	go.opencensus.io v0.23.0
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+	golang.org/x/net v0.0.0-20221004154528-8021a29435af // indirect
+	golang.org/x/sys v0.7.0 // indirect
+	golang.org/x/text v0.3.8 // indirect
 )
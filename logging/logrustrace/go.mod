@@ -0,0 +1,8 @@
+module github.com/krzysztofreczek/chi-opencensus-tracing/logging/logrustrace
+
+go 1.16
+
+require (
+	github.com/sirupsen/logrus v1.8.1
+	go.opencensus.io v0.23.0
+)
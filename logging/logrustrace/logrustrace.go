@@ -0,0 +1,31 @@
+// Package logrustrace joins the logrus logs with the traces, stamping them with the IDs of the span of their context
+package logrustrace
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// TraceIDKey is the key of the log field holding the hex-encoded trace ID
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the key of the log field holding the hex-encoded span ID
+	SpanIDKey = "span_id"
+)
+
+// TraceLogrusFields returns the fields of the trace and span IDs of the span of the context, e.g. the request span,
+// e.g. logrus.WithFields(logrustrace.TraceLogrusFields(r.Context())).Info("handled").
+// No fields are returned if there is no span.
+func TraceLogrusFields(ctx context.Context) logrus.Fields {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return logrus.Fields{}
+	}
+	sc := span.SpanContext()
+	return logrus.Fields{
+		TraceIDKey: sc.TraceID.String(),
+		SpanIDKey:  sc.SpanID.String(),
+	}
+}
@@ -0,0 +1,38 @@
+package logrustrace
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.opencensus.io/trace"
+)
+
+func TestTraceLogrusFields(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetOutput(ioutil.Discard)
+
+	ctx, span := trace.StartSpan(context.Background(), "span")
+	defer span.End()
+	logger.WithFields(TraceLogrusFields(ctx)).Info("handled")
+
+	fields := hook.LastEntry().Data
+
+	expectedFields := logrus.Fields{
+		TraceIDKey: span.SpanContext().TraceID.String(),
+		SpanIDKey:  span.SpanContext().SpanID.String(),
+	}
+	for key, value := range expectedFields {
+		if fields[key] != value {
+			t.Fatalf("Expected the log field of key '%s' to have value '%s', while it was '%v'", key, value, fields[key])
+		}
+	}
+}
+
+func TestTraceLogrusFields_no_span(t *testing.T) {
+	if fields := TraceLogrusFields(context.Background()); len(fields) != 0 {
+		t.Fatalf("Expected no fields without a span, while there were %d", len(fields))
+	}
+}
@@ -0,0 +1,3 @@
+// Package slogtrace joins the log/slog records with the traces, stamping them with the IDs of the span of their context.
+// It requires Go 1.21 or newer.
+package slogtrace
@@ -0,0 +1,78 @@
+//go:build go1.21
+// +build go1.21
+
+package slogtrace
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	// TraceIDKey is the key of the log attribute holding the hex-encoded trace ID
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the key of the log attribute holding the hex-encoded span ID
+	SpanIDKey = "span_id"
+)
+
+// Handler wraps a slog.Handler, adding the trace and span IDs of the span of the context, e.g. the request span,
+// to every record logged with a context, e.g. with slog.InfoContext(r.Context(), ...).
+// Records logged without a span in their context are passed on as they are.
+type Handler struct {
+	next slog.Handler
+}
+
+// NewHandler returns the handler passing the records stamped with the trace and span IDs to the next handler
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+// Enabled reports whether the next handler handles the records of the level
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the trace and span IDs of the span of the context to the record and passes it to the next handler
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := traceAttrs(ctx); ok {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs returns the handler whose next handler has the attributes added
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewHandler(h.next.WithAttrs(attrs))
+}
+
+// WithGroup returns the handler whose next handler has the group opened,
+// the trace and span IDs are then added to the group as well
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return NewHandler(h.next.WithGroup(name))
+}
+
+// LoggerFromContext returns the default logger, see slog.Default, with the trace and span IDs
+// of the span of the context, e.g. the request span, added to every record
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	attrs, ok := traceAttrs(ctx)
+	if !ok {
+		return logger
+	}
+	return slog.New(logger.Handler().WithAttrs(attrs))
+}
+
+func traceAttrs(ctx context.Context) ([]slog.Attr, bool) {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return nil, false
+	}
+	sc := span.SpanContext()
+	return []slog.Attr{
+		slog.String(TraceIDKey, sc.TraceID.String()),
+		slog.String(SpanIDKey, sc.SpanID.String()),
+	}, true
+}
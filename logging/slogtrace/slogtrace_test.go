@@ -0,0 +1,73 @@
+//go:build go1.21
+// +build go1.21
+
+package slogtrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestHandler(t *testing.T) {
+	buff := &bytes.Buffer{}
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buff, nil))).With("service", "test")
+
+	ctx, span := trace.StartSpan(context.Background(), "span")
+	defer span.End()
+	logger.InfoContext(ctx, "handled")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buff.Bytes(), &record); err != nil {
+		t.Fatalf("Expected the record to be logged as JSON, while it failed with: %s", err)
+	}
+
+	expectedAttributes := map[string]string{
+		"service":  "test",
+		TraceIDKey: span.SpanContext().TraceID.String(),
+		SpanIDKey:  span.SpanContext().SpanID.String(),
+	}
+	for key, value := range expectedAttributes {
+		if record[key] != value {
+			t.Fatalf("Expected the record attribute of key '%s' to have value '%s', while it was '%v'", key, value, record[key])
+		}
+	}
+}
+
+func TestHandler_no_span(t *testing.T) {
+	buff := &bytes.Buffer{}
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buff, nil)))
+
+	logger.InfoContext(context.Background(), "handled")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buff.Bytes(), &record); err != nil {
+		t.Fatalf("Expected the record to be logged as JSON, while it failed with: %s", err)
+	}
+	if _, ok := record[TraceIDKey]; ok {
+		t.Fatalf("Expected no trace ID in the record logged without a span")
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	buff := &bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buff, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	ctx, span := trace.StartSpan(context.Background(), "span")
+	defer span.End()
+	LoggerFromContext(ctx).Info("handled")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buff.Bytes(), &record); err != nil {
+		t.Fatalf("Expected the record to be logged as JSON, while it failed with: %s", err)
+	}
+	if record[TraceIDKey] != span.SpanContext().TraceID.String() {
+		t.Fatalf("Expected the record to carry the trace ID '%s', while it was '%v'", span.SpanContext().TraceID, record[TraceIDKey])
+	}
+}
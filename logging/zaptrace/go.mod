@@ -0,0 +1,8 @@
+module github.com/krzysztofreczek/chi-opencensus-tracing/logging/zaptrace
+
+go 1.16
+
+require (
+	go.opencensus.io v0.23.0
+	go.uber.org/zap v1.21.0
+)
@@ -0,0 +1,30 @@
+// Package zaptrace joins the zap logs with the traces, stamping them with the IDs of the span of their context
+package zaptrace
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// TraceIDKey is the key of the log field holding the hex-encoded trace ID
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the key of the log field holding the hex-encoded span ID
+	SpanIDKey = "span_id"
+)
+
+// TraceFields returns the fields of the trace and span IDs of the span of the context, e.g. the request span,
+// e.g. logger.Info("handled", zaptrace.TraceFields(r.Context())...). No fields are returned if there is no span.
+func TraceFields(ctx context.Context) []zap.Field {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	sc := span.SpanContext()
+	return []zap.Field{
+		zap.String(TraceIDKey, sc.TraceID.String()),
+		zap.String(SpanIDKey, sc.SpanID.String()),
+	}
+}
@@ -0,0 +1,37 @@
+package zaptrace
+
+import (
+	"context"
+	"testing"
+
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTraceFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	ctx, span := trace.StartSpan(context.Background(), "span")
+	defer span.End()
+	logger.Info("handled", TraceFields(ctx)...)
+
+	fields := logs.All()[0].ContextMap()
+
+	expectedFields := map[string]string{
+		TraceIDKey: span.SpanContext().TraceID.String(),
+		SpanIDKey:  span.SpanContext().SpanID.String(),
+	}
+	for key, value := range expectedFields {
+		if fields[key] != value {
+			t.Fatalf("Expected the log field of key '%s' to have value '%s', while it was '%v'", key, value, fields[key])
+		}
+	}
+}
+
+func TestTraceFields_no_span(t *testing.T) {
+	if fields := TraceFields(context.Background()); len(fields) != 0 {
+		t.Fatalf("Expected no fields without a span, while there were %d", len(fields))
+	}
+}
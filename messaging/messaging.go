@@ -0,0 +1,75 @@
+// Package messaging propagates the span context over the headers of queued messages, e.g. Kafka, AMQP or SQS,
+// so the message handlers continue the traces of the requests the messages are produced by
+package messaging
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+// Carrier gives access to the string headers of a message
+type Carrier interface {
+	// Get returns the value of the header, or an empty string if the header is not set
+	Get(key string) string
+	// Set sets the value of the header
+	Set(key, value string)
+}
+
+// MapCarrier is a carrier of the headers stored in a map
+type MapCarrier map[string]string
+
+// Get returns the value of the header
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set sets the value of the header
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Inject writes the span context of the span of the context to the carrier, in all the formats
+// of the provided propagators, or of propagation.DefaultChain if none are provided.
+// The header names are lowercase, e.g. "traceparent".
+func Inject(ctx context.Context, carrier Carrier, propagators ...propagation.Propagator) {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return
+	}
+	h := propagation.SpanContextToHeaders(span.SpanContext(), propagators...)
+	for name, values := range h {
+		if len(values) > 0 {
+			carrier.Set(strings.ToLower(name), values[0])
+		}
+	}
+}
+
+// Extract resolves the span context carried by the carrier using the first of the provided propagators
+// recognizing it, or of propagation.DefaultChain if none are provided.
+// The headers are looked up by their lowercase and canonical names.
+func Extract(carrier Carrier, propagators ...propagation.Propagator) (sc trace.SpanContext, ok bool) {
+	h := make(http.Header)
+	for _, name := range propagation.HeaderNames() {
+		value := carrier.Get(strings.ToLower(name))
+		if value == "" {
+			value = carrier.Get(name)
+		}
+		if value != "" {
+			h[name] = []string{value}
+		}
+	}
+	return propagation.HeadersToSpanContext(h, propagators...)
+}
+
+// StartConsumerSpan starts the span of a consumed message, as a child of the span context carried by the carrier,
+// or as a new root span if the carrier carries none, see Extract. The span has to be ended by the caller.
+func StartConsumerSpan(ctx context.Context, carrier Carrier, name string, propagators ...propagation.Propagator) (context.Context, *trace.Span) {
+	if sc, ok := Extract(carrier, propagators...); ok {
+		return trace.StartSpanWithRemoteParent(ctx, name, sc, trace.WithSpanKind(trace.SpanKindServer))
+	}
+	return trace.StartSpan(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+}
@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/middleware"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/tracingtest"
+	"go.opencensus.io/trace"
+)
+
+func TestStartConsumerSpan_continues_the_request_trace(t *testing.T) {
+	exporter := tracingtest.Register(t)
+
+	message := MapCarrier{}
+	var requestSpanContext trace.SpanContext
+
+	r := chi.NewRouter()
+	r.Use(middleware.OpencensusTracing())
+	r.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		requestSpanContext = trace.FromContext(r.Context()).SpanContext()
+		Inject(r.Context(), message)
+	})
+
+	req, _ := http.NewRequest("POST", "/orders", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if message.Get("traceparent") == "" {
+		t.Fatalf("Expected the message to carry the traceparent header, while the headers were %v", message)
+	}
+
+	_, span := StartConsumerSpan(context.Background(), message, "consume orders")
+	span.End()
+
+	consumerSpan := exporter.Span("consume orders")
+	if consumerSpan == nil {
+		t.Fatal("Expected the consumer span to be exported")
+	}
+	if consumerSpan.TraceID != requestSpanContext.TraceID {
+		t.Fatalf("Expected the consumer span to be of trace '%s', while it was '%s'", requestSpanContext.TraceID, consumerSpan.TraceID)
+	}
+	if consumerSpan.ParentSpanID != requestSpanContext.SpanID {
+		t.Fatalf("Expected the consumer span to be a child of '%s', while its parent was '%s'", requestSpanContext.SpanID, consumerSpan.ParentSpanID)
+	}
+}
+
+func TestExtract_canonical_header_names(t *testing.T) {
+	message := MapCarrier{
+		"X-Datadog-Trace-Id":  "1234",
+		"X-Datadog-Parent-Id": "5678",
+	}
+
+	sc, ok := Extract(message, propagation.Datadog())
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+	if sc.SpanID != (trace.SpanID{0, 0, 0, 0, 0, 0, 0x16, 0x2e}) {
+		t.Fatalf("Expected the span ID to be extracted, while it was '%s'", sc.SpanID)
+	}
+}
+
+func TestStartConsumerSpan_without_span_context(t *testing.T) {
+	exporter := tracingtest.Register(t)
+
+	_, span := StartConsumerSpan(context.Background(), MapCarrier{}, "consume orders")
+	span.End()
+
+	consumerSpan := exporter.Span("consume orders")
+	if consumerSpan == nil {
+		t.Fatal("Expected the consumer span to be exported")
+	}
+	if consumerSpan.ParentSpanID != (trace.SpanID{}) {
+		t.Fatalf("Expected the consumer span to be a root span, while its parent was '%s'", consumerSpan.ParentSpanID)
+	}
+}
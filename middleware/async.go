@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+type asyncLinkKey struct{}
+
+// detachedContext carries the values of its parent, but neither its cancellation nor its deadline
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// DetachSpan returns a context for the work outliving the request, e.g. of a goroutine spawned by the handler.
+// It carries the values of the provided context, e.g. the baggage, but neither its cancellation, its deadline
+// nor its span, which is linked by the spans started from the returned context with StartAsyncSpan.
+func DetachSpan(ctx context.Context) context.Context {
+	detached := context.Context(detachedContext{parent: ctx})
+	if span := trace.FromContext(ctx); span != nil {
+		detached = context.WithValue(detached, asyncLinkKey{}, span.SpanContext())
+		detached = trace.NewContext(detached, nil)
+	}
+	return detached
+}
+
+// StartAsyncSpan starts a root span for fire-and-forget work, linked to the span of the context, e.g. the request span,
+// and sampled if that span is. Unlike StartSpan, the span may end after the request span, neither of them is lost.
+// The returned context carries the span and is detached from the provided one, see DetachSpan.
+// The span is ended by calling the returned func.
+func StartAsyncSpan(ctx context.Context, name string) (context.Context, func()) {
+	ctx = DetachSpan(ctx)
+	sc, linked := ctx.Value(asyncLinkKey{}).(trace.SpanContext)
+	if !linked {
+		ctx, span := trace.StartSpan(ctx, name)
+		return ctx, span.End
+	}
+
+	sampler := trace.NeverSample()
+	if sc.IsSampled() {
+		sampler = trace.AlwaysSample()
+	}
+	ctx, span := trace.StartSpan(ctx, name, trace.WithSampler(sampler))
+	span.AddLink(trace.Link{
+		TraceID: sc.TraceID,
+		SpanID:  sc.SpanID,
+		Type:    trace.LinkTypeParent,
+	})
+	return ctx, span.End
+}
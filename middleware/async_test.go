@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestStartAsyncSpan_outliving_the_request(t *testing.T) {
+	exporter := registerTestExporter()
+
+	release, done := make(chan struct{}), make(chan struct{})
+	var asyncErr error
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+	r.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		ctx, end := StartAsyncSpan(r.Context(), "send confirmation")
+		go func() {
+			defer close(done)
+			<-release
+			asyncErr = ctx.Err()
+			end()
+		}()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "POST", "/orders", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	cancel()
+	close(release)
+	<-done
+
+	if asyncErr != nil {
+		t.Fatalf("Expected the async context not to be canceled with the request, while its error was '%v'", asyncErr)
+	}
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	requestSpan, asyncSpan := exporter.collected[0], exporter.collected[1]
+	if asyncSpan.Name != "send confirmation" {
+		t.Fatalf("Expected the async span to be exported last, while it was '%s'", asyncSpan.Name)
+	}
+	if asyncSpan.ParentSpanID != (trace.SpanID{}) || asyncSpan.TraceID == requestSpan.TraceID {
+		t.Fatalf("Expected the async span to be a root span of another trace, while its parent was '%s'", asyncSpan.ParentSpanID)
+	}
+	if len(asyncSpan.Links) != 1 {
+		t.Fatalf("Expected the async span to have 1 link, while it had %d", len(asyncSpan.Links))
+	}
+	link := asyncSpan.Links[0]
+	if link.TraceID != requestSpan.TraceID || link.SpanID != requestSpan.SpanID || link.Type != trace.LinkTypeParent {
+		t.Fatalf("Expected the async span to link to the request span, while the link was %+v", link)
+	}
+}
+
+func TestStartAsyncSpan_unsampled_request(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSampler(trace.NeverSample())))
+	r.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		_, end := StartAsyncSpan(r.Context(), "send confirmation")
+		end()
+	})
+
+	req, _ := http.NewRequest("POST", "/orders", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.collected) != 0 {
+		t.Fatalf("Expected the async span to follow the sampling of the request span, while %d span(s) were collected", len(exporter.collected))
+	}
+}
+
+func TestDetachSpan(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	ctx, span := trace.StartSpan(ctx, "request")
+	defer span.End()
+
+	detached := DetachSpan(ctx)
+	cancel()
+
+	if detached.Err() != nil {
+		t.Fatal("Expected the detached context not to be canceled")
+	}
+	if trace.FromContext(detached) != nil {
+		t.Fatal("Expected the detached context to carry no span")
+	}
+	if detached.Value(key{}) != "value" {
+		t.Fatal("Expected the detached context to carry the values of its parent")
+	}
+}
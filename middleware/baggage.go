@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultBaggageHeader is the W3C Baggage header, propagating the baggage unless another header is configured
+const DefaultBaggageHeader = "baggage"
+
+type baggageKey struct{}
+
+// baggage holds the key-value pairs travelling alongside the span context, along with the header propagating them
+type baggage struct {
+	header string
+	values map[string]string
+}
+
+// WithBaggageHeader enables picking the baggage up from the header of the provided name of incoming requests,
+// e.g. DefaultBaggageHeader, to be read with GetBaggage. The baggage is propagated further in the same header
+// by RequestWithTracingSpan and Transport. Entries follow the W3C Baggage format, i.e. "tenant=acme,region=eu".
+func WithBaggageHeader(name string) Option {
+	return func(c *config) {
+		c.baggageHeader = http.CanonicalHeaderKey(name)
+	}
+}
+
+// SetBaggage returns a copy of the context carrying the baggage entry, e.g. a tenant ID,
+// propagated to the downstream services along with the span context
+func SetBaggage(ctx context.Context, key, value string) context.Context {
+	b := baggageFromContext(ctx)
+	values := make(map[string]string, len(b.values)+1)
+	for k, v := range b.values {
+		values[k] = v
+	}
+	values[key] = value
+	return context.WithValue(ctx, baggageKey{}, baggage{header: b.header, values: values})
+}
+
+// GetBaggage returns the value of the baggage entry carried by the context, or an empty string if there is none
+func GetBaggage(ctx context.Context, key string) string {
+	return baggageFromContext(ctx).values[key]
+}
+
+func baggageFromContext(ctx context.Context) baggage {
+	b, ok := ctx.Value(baggageKey{}).(baggage)
+	if !ok {
+		return baggage{header: http.CanonicalHeaderKey(DefaultBaggageHeader)}
+	}
+	return b
+}
+
+// contextWithRequestBaggage returns a copy of the context carrying the baggage of the request, if there is any
+func contextWithRequestBaggage(ctx context.Context, r *http.Request, cfg *config) context.Context {
+	if cfg.baggageHeader == "" {
+		return ctx
+	}
+	values := parseBaggage(r.Header[cfg.baggageHeader])
+	if len(values) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, baggageKey{}, baggage{header: cfg.baggageHeader, values: values})
+}
+
+// injectBaggage sets the baggage carried by the context in the request headers
+func injectBaggage(ctx context.Context, h http.Header) {
+	b := baggageFromContext(ctx)
+	if len(b.values) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(b.values))
+	for key := range b.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.ReplaceAll(url.QueryEscape(b.values[key]), "+", "%20")
+		entries = append(entries, key+"="+value)
+	}
+	h[b.header] = []string{strings.Join(entries, ",")}
+}
+
+// parseBaggage resolves the baggage entries, skipping the malformed ones and dropping the entry properties
+func parseBaggage(headerValues []string) map[string]string {
+	var values map[string]string
+	for _, headerValue := range headerValues {
+		for _, entry := range strings.Split(headerValue, ",") {
+			if i := strings.IndexByte(entry, ';'); i >= 0 {
+				entry = entry[:i]
+			}
+			kv := strings.SplitN(entry, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key := strings.TrimSpace(kv[0])
+			value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+			if key == "" || err != nil {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[key] = value
+		}
+	}
+	return values
+}
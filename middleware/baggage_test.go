@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_baggage(t *testing.T) {
+	_ = registerTestExporter()
+
+	var outgoing *http.Request
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithBaggageHeader("X-Baggage")))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		ctx := SetBaggage(r.Context(), "user", "John Doe")
+		outgoing, _ = http.NewRequest("GET", "/downstream", nil)
+		AddTracingSpanToRequest(ctx, outgoing)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Baggage", "tenant=acme;ttl=60, region = eu-west,malformed")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedBaggage := "region=eu-west,tenant=acme,user=John%20Doe"
+	if outgoing.Header.Get("X-Baggage") != expectedBaggage {
+		t.Fatalf("Expected the propagated baggage to be '%s', while it was '%s'", expectedBaggage, outgoing.Header.Get("X-Baggage"))
+	}
+}
+
+func TestGetBaggage(t *testing.T) {
+	ctx := SetBaggage(context.Background(), "tenant", "acme")
+	child := SetBaggage(ctx, "tenant", "other")
+
+	if GetBaggage(ctx, "tenant") != "acme" {
+		t.Fatalf("Expected the baggage of the parent context not to be modified, while it was '%s'", GetBaggage(ctx, "tenant"))
+	}
+	if GetBaggage(child, "tenant") != "other" {
+		t.Fatalf("Expected the baggage entry to be 'other', while it was '%s'", GetBaggage(child, "tenant"))
+	}
+	if GetBaggage(child, "missing") != "" {
+		t.Fatalf("Expected no baggage entry of a missing key")
+	}
+
+	outgoing, _ := http.NewRequest("GET", "/downstream", nil)
+	injectBaggage(child, outgoing.Header)
+	if outgoing.Header.Get(DefaultBaggageHeader) != "tenant=other" {
+		t.Fatalf("Expected the baggage to be propagated in the default header, while it was '%s'", outgoing.Header.Get(DefaultBaggageHeader))
+	}
+}
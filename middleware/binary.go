@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"unicode/utf8"
+)
+
+// BinaryPayloadPolicy defines how payloads which are not text are recorded as span attributes
+type BinaryPayloadPolicy int
+
+const (
+	// BinaryPayloadSize records the size of the payload only, e.g. "[binary, 1024 bytes]"
+	BinaryPayloadSize BinaryPayloadPolicy = iota
+	// BinaryPayloadHex records the captured payload hex encoded, truncated at the payload size limit
+	BinaryPayloadHex
+	// BinaryPayloadBase64 records the captured payload base64 encoded, truncated at the payload size limit
+	BinaryPayloadBase64
+	// BinaryPayloadDigest records the size and the SHA-256 digest of the captured payload,
+	// e.g. "[binary, 1024 bytes, sha256:9f86d0...]"
+	BinaryPayloadDigest
+)
+
+// WithBinaryPayloadPolicy sets how payloads which are not valid UTF-8 text, or hold control characters,
+// are recorded, as such attribute values are rejected by some exporters. BinaryPayloadSize is used by default.
+func WithBinaryPayloadPolicy(policy BinaryPayloadPolicy) Option {
+	return func(c *config) {
+		c.binaryPayloadPolicy = policy
+	}
+}
+
+// formatPayload turns the captured payload into the attribute value,
+// given the number of bytes transferred and whether the capture is truncated
+func formatPayload(payload []byte, transferred int64, truncated bool, cfg *config) string {
+	if !isBinaryPayload(payload, truncated) {
+		return truncatePayload(string(payload), cfg.payloadSizeLimit, truncated)
+	}
+
+	switch cfg.binaryPayloadPolicy {
+	case BinaryPayloadHex:
+		return truncatePayload(hex.EncodeToString(payload), cfg.payloadSizeLimit, truncated)
+	case BinaryPayloadBase64:
+		return truncatePayload(base64.StdEncoding.EncodeToString(payload), cfg.payloadSizeLimit, truncated)
+	case BinaryPayloadDigest:
+		digest := sha256.Sum256(payload)
+		return binaryPayloadSize(transferred) + ", sha256:" + hex.EncodeToString(digest[:]) + "]"
+	default:
+		return binaryPayloadSize(transferred) + "]"
+	}
+}
+
+func binaryPayloadSize(n int64) string {
+	return "[binary, " + strconv.FormatInt(n, 10) + " bytes"
+}
+
+// isBinaryPayload tells whether the payload is not valid UTF-8 or holds control characters other than whitespace.
+// A rune cut by the truncation of the capture does not make the payload binary.
+func isBinaryPayload(payload []byte, truncated bool) bool {
+	if truncated {
+		for i := 0; i < utf8.UTFMax-1 && len(payload) > 0 && !utf8.Valid(payload); i++ {
+			payload = payload[:len(payload)-1]
+		}
+	}
+	if !utf8.Valid(payload) {
+		return true
+	}
+	for _, b := range payload {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r') || b == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_binary_payload(t *testing.T) {
+	exporter := registerTestExporter()
+
+	respBody := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0xff}
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(respBody)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	expectedResponsePayload := "[binary, 10 bytes]"
+	if exporter.collected[0].Attributes[spanResponsePayloadAttributeKey] != expectedResponsePayload {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", spanResponsePayloadAttributeKey, expectedResponsePayload)
+	}
+}
+
+func TestFormatPayload(t *testing.T) {
+	binaryPayload := []byte{0x00, 0x01, 0xfe, 0xff}
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		truncated bool
+		policy    BinaryPayloadPolicy
+		expected  string
+	}{
+		{name: "text", payload: []byte("zażółć\n"), policy: BinaryPayloadHex, expected: "zażółć\n"},
+		{name: "text cut within a rune", payload: []byte("zażó")[:4], truncated: true, expected: string([]byte("zażó")[:4]) + payloadTruncatedMessage},
+		{name: "size", payload: binaryPayload, policy: BinaryPayloadSize, expected: "[binary, 4 bytes]"},
+		{name: "hex", payload: binaryPayload, policy: BinaryPayloadHex, expected: "0001feff"},
+		{name: "base64", payload: binaryPayload, policy: BinaryPayloadBase64, expected: "AAH+/w=="},
+		{
+			name:     "digest",
+			payload:  binaryPayload,
+			policy:   BinaryPayloadDigest,
+			expected: "[binary, 4 bytes, sha256:c5dbae22661af6db18a1f676db82a7ef7de46d27c3a263a872f00478b0d99fc4]",
+		},
+		{name: "control characters", payload: []byte("text\x1b[0m"), policy: BinaryPayloadSize, expected: "[binary, 8 bytes]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig([]Option{WithBinaryPayloadPolicy(tt.policy)})
+			value := formatPayload(tt.payload, int64(len(tt.payload)), tt.truncated, cfg)
+			if value != tt.expected {
+				t.Fatalf("Expected the payload to be formatted as '%s', while it was '%s'", tt.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"go.opencensus.io/trace"
+)
+
+const spanMiddlewareShortCircuitAttributeKey = "http.middleware.short_circuit"
+
+type middlewareSpanKey struct{}
+
+// middlewareSpan is the span of a middleware traced with TraceMiddleware, along with the span it is a child of
+type middlewareSpan struct {
+	span   *trace.Span
+	parent *trace.Span
+	// passed is set atomically once the middleware calls the next handler, which may happen in another goroutine
+	passed int32
+}
+
+// TraceMiddleware wraps the middleware, e.g. a third-party authentication, rate limiting or compression one,
+// in a child span of the request span of the provided name, so the middlewares show up as separate segments
+// on the trace timeline, e.g. r.Use(TraceMiddleware("auth", jwtauth.Authenticator)). The span ends once the middleware
+// calls the next handler, which is handed the request span back. The span of a middleware answering the request itself,
+// e.g. rejecting it, ends along with the middleware and is flagged with the http.middleware.short_circuit attribute.
+func TraceMiddleware(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ms, ok := r.Context().Value(middlewareSpanKey{}).(*middlewareSpan); ok && ms.pass() {
+				r = r.WithContext(trace.NewContext(r.Context(), ms.parent))
+			}
+			next.ServeHTTP(w, r)
+		}))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			parent := trace.FromContext(r.Context())
+			if parent == nil {
+				wrapped.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, span := trace.StartSpan(r.Context(), name)
+			ms := &middlewareSpan{span: span, parent: parent}
+			defer ms.end()
+
+			wrapped.ServeHTTP(w, r.WithContext(context.WithValue(ctx, middlewareSpanKey{}, ms)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// pass ends the span once the middleware calls the next handler, it returns false if it has already been called
+func (s *middlewareSpan) pass() bool {
+	if !atomic.CompareAndSwapInt32(&s.passed, 0, 1) {
+		return false
+	}
+	s.span.End()
+	return true
+}
+
+// end ends the span of the middleware which has not called the next handler
+func (s *middlewareSpan) end() {
+	if !atomic.CompareAndSwapInt32(&s.passed, 0, 1) {
+		return
+	}
+	s.span.AddAttributes(trace.BoolAttribute(spanMiddlewareShortCircuitAttributeKey, true))
+	s.span.End()
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+	tests := []struct {
+		name                 string
+		middleware           func(next http.Handler) http.Handler
+		expectedShortCircuit bool
+	}{
+		{
+			name: "passing",
+			middleware: func(next http.Handler) http.Handler {
+				return next
+			},
+		},
+		{
+			name: "short circuit",
+			middleware: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusUnauthorized)
+				})
+			},
+			expectedShortCircuit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			var handlerSpan *trace.Span
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing())
+			r.Use(TraceMiddleware("auth", tt.middleware))
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				handlerSpan = SpanFromRequest(r)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 2
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			middlewareSpan, requestSpan := exporter.collected[0], exporter.collected[1]
+
+			expectedSpanName := "auth"
+			if middlewareSpan.Name != expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", expectedSpanName, middlewareSpan.Name)
+			}
+			if middlewareSpan.ParentSpanID != requestSpan.SpanID {
+				t.Fatal("Expected the middleware span to be a child of the request span")
+			}
+			if handlerSpan != nil && handlerSpan.SpanContext().SpanID != requestSpan.SpanID {
+				t.Fatal("Expected the handler to be handed the request span")
+			}
+			if shortCircuit, _ := middlewareSpan.Attributes["http.middleware.short_circuit"].(bool); shortCircuit != tt.expectedShortCircuit {
+				t.Fatalf("Expected the middleware span to be flagged as short circuit: %t", tt.expectedShortCircuit)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	dnsStartAnnotationMessage          = "DNS lookup started"
+	dnsDoneAnnotationMessage           = "DNS lookup done"
+	connectStartAnnotationMessage      = "Connect started"
+	connectDoneAnnotationMessage       = "Connect done"
+	tlsHandshakeStartAnnotationMessage = "TLS handshake started"
+	tlsHandshakeDoneAnnotationMessage  = "TLS handshake done"
+	gotConnAnnotationMessage           = "Connection obtained"
+	wroteRequestAnnotationMessage      = "Request written"
+	firstResponseByteAnnotationMessage = "First response byte received"
+
+	clientTraceHostAttributeKey       = "net.host"
+	clientTraceAddressesAttributeKey  = "net.addresses"
+	clientTraceNetworkAttributeKey    = "net.network"
+	clientTraceAddressAttributeKey    = "net.address"
+	clientTraceReusedAttributeKey     = "net.reused"
+	clientTraceWasIdleAttributeKey    = "net.was_idle"
+	clientTraceTLSVersionAttributeKey = "tls.version"
+	clientTraceErrorAttributeKey      = "error"
+)
+
+// newClientTrace returns the hooks annotating the client span with the network events of the request,
+// the annotations are timestamped, giving the latency breakdown of the request on the span timeline
+func newClientTrace(span *trace.Span) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			span.Annotate([]trace.Attribute{trace.StringAttribute(clientTraceHostAttributeKey, info.Host)}, dnsStartAnnotationMessage)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			addrs := make([]string, 0, len(info.Addrs))
+			for _, addr := range info.Addrs {
+				addrs = append(addrs, addr.String())
+			}
+			attrs := []trace.Attribute{trace.StringAttribute(clientTraceAddressesAttributeKey, strings.Join(addrs, ","))}
+			span.Annotate(withErrorAttribute(attrs, info.Err), dnsDoneAnnotationMessage)
+		},
+		ConnectStart: func(network, addr string) {
+			span.Annotate(connectAttributes(network, addr), connectStartAnnotationMessage)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			span.Annotate(withErrorAttribute(connectAttributes(network, addr), err), connectDoneAnnotationMessage)
+		},
+		TLSHandshakeStart: func() {
+			span.Annotate(nil, tlsHandshakeStartAnnotationMessage)
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			attrs := []trace.Attribute{trace.Int64Attribute(clientTraceTLSVersionAttributeKey, int64(state.Version))}
+			span.Annotate(withErrorAttribute(attrs, err), tlsHandshakeDoneAnnotationMessage)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.Annotate([]trace.Attribute{
+				trace.BoolAttribute(clientTraceReusedAttributeKey, info.Reused),
+				trace.BoolAttribute(clientTraceWasIdleAttributeKey, info.WasIdle),
+			}, gotConnAnnotationMessage)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			span.Annotate(withErrorAttribute(nil, info.Err), wroteRequestAnnotationMessage)
+		},
+		GotFirstResponseByte: func() {
+			span.Annotate(nil, firstResponseByteAnnotationMessage)
+		},
+	}
+}
+
+func connectAttributes(network, addr string) []trace.Attribute {
+	return []trace.Attribute{
+		trace.StringAttribute(clientTraceNetworkAttributeKey, network),
+		trace.StringAttribute(clientTraceAddressAttributeKey, addr),
+	}
+}
+
+func withErrorAttribute(attrs []trace.Attribute, err error) []trace.Attribute {
+	if err == nil {
+		return attrs
+	}
+	return append(attrs, trace.StringAttribute(clientTraceErrorAttributeKey, err.Error()))
+}
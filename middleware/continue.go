@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanRequestFirstByteAttributeKey = "http.request.first_byte_ms"
+	requestBodyAnnotationMessage     = "Request body read started"
+)
+
+// expectsContinue tells whether the client waits for the 100 Continue response before sending the request body,
+// which the server sends once the handler starts reading the body
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+}
+
+// requestBodyStarted marks the moment the handler starts reading the body of a request expecting 100 Continue,
+// the client holding the body back until then
+func (s *serverSpan) requestBodyStarted() {
+	s.span.Annotate([]trace.Attribute{
+		trace.Float64Attribute(spanRequestFirstByteAttributeKey, durationMillis(s.cfg.now().Sub(s.start))),
+	}, requestBodyAnnotationMessage)
+}
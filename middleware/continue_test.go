@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_expect_continue(t *testing.T) {
+	exporter := registerTestExporter()
+
+	done := make(chan struct{})
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			close(done)
+		})
+	})
+	r.Use(OpencensusTracing())
+	r.Post("/upload", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	got100Continue := false
+	trace := &httptrace.ClientTrace{
+		Got100Continue: func() { got100Continue = true },
+	}
+	req, _ := http.NewRequest("POST", server.URL+"/upload", strings.NewReader("PAYLOAD"))
+	req.Header.Set("Expect", "100-continue")
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: time.Minute}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed, while it failed with: %s", err)
+	}
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	<-done
+
+	if !got100Continue {
+		t.Fatal("Expected the client to receive the 100 Continue response")
+	}
+	if string(respBody) != "PAYLOAD" {
+		t.Fatalf("Expected the request body to reach the handler, while the response was '%s'", respBody)
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	for _, annotation := range exporter.collected[0].Annotations {
+		if annotation.Message == requestBodyAnnotationMessage {
+			if _, ok := annotation.Attributes[spanRequestFirstByteAttributeKey]; !ok {
+				t.Fatal("Expected the annotation to record the time the first body byte was read")
+			}
+			return
+		}
+	}
+	t.Fatalf("Expected the span to have the '%s' annotation", requestBodyAnnotationMessage)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/exporters/console"
+	"go.opencensus.io/trace"
+)
+
+var (
+	// debugSpans maps the IDs of the request spans of the routers with debug logging to their console exporters,
+	// which the single debug exporter registered on first use hands the spans over to, see WithDebugLogging
+	debugSpans        sync.Map
+	debugExporterOnce sync.Once
+)
+
+// WithDebugLogging prints every request span of the router to the logger in a human-readable form, see console.Format,
+// and samples all the requests of the router, so spans show up during local development without a tracing backend.
+// The spans of other routers and libraries are not printed.
+func WithDebugLogging(logger *log.Logger) Option {
+	return func(c *config) {
+		c.debugExporter = console.NewLoggerExporter(logger)
+		WithSampler(trace.AlwaysSample())(c)
+	}
+}
+
+// logDebugSpan has the request span printed by the console exporter once ended,
+// it returns the function forgetting the span, to be called once the span is ended or dropped
+func logDebugSpan(span *trace.Span, exporter *console.Exporter) func() {
+	debugExporterOnce.Do(func() {
+		trace.RegisterExporter(debugExporter{})
+	})
+	spanID := span.SpanContext().SpanID
+	debugSpans.Store(spanID, exporter)
+	return func() {
+		debugSpans.Delete(spanID)
+	}
+}
+
+// debugExporter prints the request spans of the routers with debug logging only
+type debugExporter struct{}
+
+func (debugExporter) ExportSpan(sd *trace.SpanData) {
+	if exporter, ok := debugSpans.Load(sd.SpanID); ok {
+		exporter.(*console.Exporter).ExportSpan(sd)
+	}
+}
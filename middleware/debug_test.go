@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_debug_logging(t *testing.T) {
+	buff := &bytes.Buffer{}
+	logger := log.New(buff, "", 0)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithDebugLogging(logger)))
+	r.Get("/debug/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/debug/1", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedLines := []string{
+		"[GET] /debug/{id} ",
+		"    http.path: /debug/1",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(buff.String(), line) {
+			t.Fatalf("Expected the logged span to contain '%s', while it was:\n%s", line, buff.String())
+		}
+	}
+}
+
+func TestOpencensusTracing_debug_logging_on_several_routers(t *testing.T) {
+	buff := &bytes.Buffer{}
+	logger := log.New(buff, "", 0)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithDebugLogging(logger)))
+	r.With(OpencensusTracing(WithDebugLogging(logger))).Get("/debug/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/debug/1", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 2
+	if n := strings.Count(buff.String(), "[GET] /debug/{id} "); n != expectedNumberOfSpans {
+		t.Fatalf("Expected %d span(s) to be logged once each, while there were %d logged:\n%s", expectedNumberOfSpans, n, buff.String())
+	}
+}
+
+func TestOpencensusTracing_debug_logging_of_other_routers(t *testing.T) {
+	buff := &bytes.Buffer{}
+	logger := log.New(buff, "", 0)
+
+	debugged := chi.NewRouter()
+	debugged.Use(OpencensusTracing(WithDebugLogging(logger)))
+	debugged.Get("/debug/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	other := chi.NewRouter()
+	other.Use(OpencensusTracing(WithSampler(trace.AlwaysSample())))
+	other.Get("/other/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/debug/1", nil)
+	debugged.ServeHTTP(httptest.NewRecorder(), req)
+	req, _ = http.NewRequest("GET", "/other/1", nil)
+	other.ServeHTTP(httptest.NewRecorder(), req)
+
+	if n := strings.Count(buff.String(), "[GET] /debug/{id} "); n != 1 {
+		t.Fatalf("Expected the span of the router to be logged once, while it was logged %d time(s):\n%s", n, buff.String())
+	}
+	if strings.Contains(buff.String(), "/other/") {
+		t.Fatalf("Expected the spans of other routers not to be logged, while they were:\n%s", buff.String())
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// WithDebugHeader forces the sampling of the requests carrying the header of the provided name and value,
+// regardless of the sampler and of the sampling decision of the parent span, e.g. ("X-Debug-Trace", "1"),
+// to trace a single problematic request on demand. In production the value should be a shared secret,
+// so the header cannot be abused to sample all the requests.
+func WithDebugHeader(name, value string) Option {
+	return func(c *config) {
+		c.debugHeaderName = http.CanonicalHeaderKey(name)
+		c.debugHeaderValue = []byte(value)
+	}
+}
+
+func (c *config) isDebugRequest(r *http.Request) bool {
+	if c.debugHeaderName == "" {
+		return false
+	}
+	values := r.Header[c.debugHeaderName]
+	if len(values) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(values[0]), c.debugHeaderValue) == 1
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_debug_header(t *testing.T) {
+	tests := []struct {
+		name            string
+		headerValue     string
+		expectedSampled bool
+	}{
+		{
+			name:            "matching secret",
+			headerValue:     "secret",
+			expectedSampled: true,
+		},
+		{
+			name:            "other value",
+			headerValue:     "1",
+			expectedSampled: false,
+		},
+		{
+			name:            "no header",
+			expectedSampled: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sampled bool
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(WithSampler(trace.NeverSample()), WithDebugHeader("X-Debug-Trace", "secret")))
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				sampled = trace.FromContext(r.Context()).SpanContext().IsSampled()
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			if tt.headerValue != "" {
+				req.Header.Set("X-Debug-Trace", tt.headerValue)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if sampled != tt.expectedSampled {
+				t.Fatalf("Expected the request span sampling to be %t, while it was %t", tt.expectedSampled, sampled)
+			}
+		})
+	}
+}
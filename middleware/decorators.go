@@ -1,27 +1,213 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
 	"io"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// maxPooledBufferSize is the capacity above which capture buffers are not returned to the pool,
+// so a single large payload does not keep its memory allocated for good
+const maxPooledBufferSize = 64 << 10
+
+var (
+	responseWriterDecoratorPool = sync.Pool{
+		New: func() interface{} { return new(responseWriterDecorator) },
+	}
+	requestBodyDecoratorPool = sync.Pool{
+		New: func() interface{} { return new(requestBodyDecorator) },
+	}
+	captureBufferPool = sync.Pool{
+		New: func() interface{} { return new(captureBuffer) },
+	}
+)
+
+// captureBuffer keeps the bytes written to it up to its limit, negative for no limit,
+// dropping the rest and marking the capture as truncated
+type captureBuffer struct {
+	buff      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.limit >= 0 {
+		room := b.limit - b.buff.Len()
+		if room < len(p) {
+			b.truncated = true
+			if room <= 0 {
+				return n, nil
+			}
+			p = p[:room]
+		}
+	}
+	_, _ = b.buff.Write(p)
+	return n, nil
+}
+
+func (b *captureBuffer) Bytes() []byte {
+	return b.buff.Bytes()
+}
+
+func (b *captureBuffer) Truncated() bool {
+	return b.truncated
+}
+
+// newCaptureBuffer returns a buffer capturing up to limit bytes, or nil if nothing is to be captured
+func newCaptureBuffer(limit int) *captureBuffer {
+	if limit == 0 {
+		return nil
+	}
+	b := captureBufferPool.Get().(*captureBuffer)
+	b.limit = limit
+	return b
+}
+
+// releaseCaptureBuffer returns the buffer to the pool, unless it is nil or too large to be pooled
+func releaseCaptureBuffer(b *captureBuffer) {
+	if b != nil && b.reset() {
+		captureBufferPool.Put(b)
+	}
+}
+
+// reset empties the buffer for reuse, telling whether it is small enough to be pooled
+func (b *captureBuffer) reset() bool {
+	if b.buff.Cap() > maxPooledBufferSize {
+		return false
+	}
+	b.buff.Reset()
+	b.limit = 0
+	b.truncated = false
+	return true
+}
+
+// responseWriterDecorator tracks the status code, the size and the timing of the response,
+// capturing its payload only if there is a capture buffer
 type responseWriterDecorator struct {
-	buff       bytes.Buffer
+	// mu serializes the writes of a handler writing the response from several goroutines,
+	// the rest of the state is read once the handler returns, except for written
+	mu         sync.Mutex
+	capture    *captureBuffer
 	statusCode int
 	w          http.ResponseWriter
+	onHijack   func()
+	// streamingDetection enables capping the capture of streaming responses, e.g. server-sent events,
+	// at the default payload size limit if there is no limit
+	streamingDetection bool
+	// written is read atomically as the response may be in progress, see WithHeartbeat
+	written int64
+	tail    streamTail
+	// firstWrite and lastWrite are the moments the handler started and last wrote the response
+	firstWrite   time.Time
+	lastWrite    time.Time
+	now          func() time.Time
+	onFirstWrite func()
+	onFlush      func()
 }
 
 func (d *responseWriterDecorator) Flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.markStreaming()
 	if w, ok := d.w.(http.Flusher); ok {
 		w.Flush()
+		d.flushed()
 	}
 }
 
-func decorateResponseWriter(w http.ResponseWriter) *responseWriterDecorator {
-	return &responseWriterDecorator{
-		buff: bytes.Buffer{},
-		w:    w,
+func (d *responseWriterDecorator) flushed() {
+	if d.onFlush != nil {
+		d.onFlush()
+	}
+}
+
+func decorateResponseWriter(w http.ResponseWriter, captureLimit int) *responseWriterDecorator {
+	d := responseWriterDecoratorPool.Get().(*responseWriterDecorator)
+	d.capture = newCaptureBuffer(captureLimit)
+	d.w = w
+	d.now = time.Now
+	return d
+}
+
+// releaseResponseWriter returns the decorator to the pool, it must not be used afterwards
+func releaseResponseWriter(d *responseWriterDecorator) {
+	releaseCaptureBuffer(d.capture)
+	d.capture = nil
+	d.statusCode = 0
+	d.w = nil
+	d.onHijack = nil
+	d.streamingDetection = false
+	d.written = 0
+	d.tail.reset()
+	d.firstWrite = time.Time{}
+	d.lastWrite = time.Time{}
+	d.now = nil
+	d.onFirstWrite = nil
+	d.onFlush = nil
+	responseWriterDecoratorPool.Put(d)
+}
+
+// composeResponseWriter extends the decorator with the optional interfaces implemented by the underlying writer,
+// i.e. http.Hijacker, io.ReaderFrom and http.Pusher, so none of them is hidden from the handler
+func composeResponseWriter(d *responseWriterDecorator) http.ResponseWriter {
+	_, isHijacker := d.w.(http.Hijacker)
+	_, isReaderFrom := d.w.(io.ReaderFrom)
+	_, isPusher := d.w.(http.Pusher)
+
+	h := hijackerDecorator{d}
+	rf := readerFromDecorator{d}
+	p := pusherDecorator{d}
+
+	switch {
+	case isHijacker && isReaderFrom && isPusher:
+		return struct {
+			*responseWriterDecorator
+			hijackerDecorator
+			readerFromDecorator
+			pusherDecorator
+		}{d, h, rf, p}
+	case isHijacker && isReaderFrom:
+		return struct {
+			*responseWriterDecorator
+			hijackerDecorator
+			readerFromDecorator
+		}{d, h, rf}
+	case isHijacker && isPusher:
+		return struct {
+			*responseWriterDecorator
+			hijackerDecorator
+			pusherDecorator
+		}{d, h, p}
+	case isReaderFrom && isPusher:
+		return struct {
+			*responseWriterDecorator
+			readerFromDecorator
+			pusherDecorator
+		}{d, rf, p}
+	case isHijacker:
+		return struct {
+			*responseWriterDecorator
+			hijackerDecorator
+		}{d, h}
+	case isReaderFrom:
+		return struct {
+			*responseWriterDecorator
+			readerFromDecorator
+		}{d, rf}
+	case isPusher:
+		return struct {
+			*responseWriterDecorator
+			pusherDecorator
+		}{d, p}
+	default:
+		return d
 	}
 }
 
@@ -30,42 +216,263 @@ func (d *responseWriterDecorator) Header() http.Header {
 }
 
 func (d *responseWriterDecorator) Write(bytes []byte) (int, error) {
-	_, _ = d.buff.Write(bytes)
-	return d.w.Write(bytes)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.markWrite()
+	d.detectStreaming()
+	if d.capture != nil {
+		_, _ = d.capture.Write(bytes)
+	}
+	n, err := d.w.Write(bytes)
+	d.tail.Write(bytes[:n])
+	atomic.AddInt64(&d.written, int64(n))
+	return n, err
 }
 
 func (d *responseWriterDecorator) WriteHeader(statusCode int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.markWrite()
+	d.detectStreaming()
 	d.statusCode = statusCode
 	d.w.WriteHeader(statusCode)
 }
 
+func (d *responseWriterDecorator) markWrite() {
+	now := d.now()
+	if d.firstWrite.IsZero() {
+		d.firstWrite = now
+		if d.onFirstWrite != nil {
+			d.onFirstWrite()
+		}
+	}
+	d.lastWrite = now
+}
+
+func (d *responseWriterDecorator) detectStreaming() {
+	if strings.HasPrefix(d.w.Header().Get("Content-Type"), "text/event-stream") {
+		d.markStreaming()
+	}
+}
+
+func (d *responseWriterDecorator) markStreaming() {
+	if d.streamingDetection && d.capture != nil && d.capture.limit < 0 {
+		d.capture.limit = defaultPayloadSizeLimit
+	}
+}
+
+// Unwrap returns the underlying response writer,
+// allowing http.ResponseController and other middlewares to reach it through the decorator
+func (d *responseWriterDecorator) Unwrap() http.ResponseWriter {
+	return d.w
+}
+
+// FlushError flushes the underlying writer, reporting an error if flushing is not supported or fails
+func (d *responseWriterDecorator) FlushError() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.markStreaming()
+	for w := d.w; w != nil; w = unwrapResponseWriter(w) {
+		switch f := w.(type) {
+		case interface{ FlushError() error }:
+			err := f.FlushError()
+			if err == nil {
+				d.flushed()
+			}
+			return err
+		case http.Flusher:
+			f.Flush()
+			d.flushed()
+			return nil
+		}
+	}
+	return http.ErrNotSupported
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection, see http.ResponseController
+func (d *responseWriterDecorator) SetReadDeadline(deadline time.Time) error {
+	for w := d.w; w != nil; w = unwrapResponseWriter(w) {
+		if s, ok := w.(interface{ SetReadDeadline(time.Time) error }); ok {
+			return s.SetReadDeadline(deadline)
+		}
+	}
+	return http.ErrNotSupported
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection, see http.ResponseController
+func (d *responseWriterDecorator) SetWriteDeadline(deadline time.Time) error {
+	for w := d.w; w != nil; w = unwrapResponseWriter(w) {
+		if s, ok := w.(interface{ SetWriteDeadline(time.Time) error }); ok {
+			return s.SetWriteDeadline(deadline)
+		}
+	}
+	return http.ErrNotSupported
+}
+
+// EnableFullDuplex allows reading the request body after writing the response, see http.ResponseController
+func (d *responseWriterDecorator) EnableFullDuplex() error {
+	for w := d.w; w != nil; w = unwrapResponseWriter(w) {
+		if e, ok := w.(interface{ EnableFullDuplex() error }); ok {
+			return e.EnableFullDuplex()
+		}
+	}
+	return http.ErrNotSupported
+}
+
+func unwrapResponseWriter(w http.ResponseWriter) http.ResponseWriter {
+	if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
 func (d *responseWriterDecorator) Payload() []byte {
-	return d.buff.Bytes()
+	if d.capture == nil {
+		return nil
+	}
+	return d.capture.Bytes()
+}
+
+// PayloadTruncated tells whether the response was longer than the captured payload
+func (d *responseWriterDecorator) PayloadTruncated() bool {
+	if d.capture == nil {
+		return d.BytesWritten() > 0
+	}
+	return d.capture.Truncated()
+}
+
+// BytesWritten returns the number of response bytes written, regardless of how many were captured
+func (d *responseWriterDecorator) BytesWritten() int64 {
+	return atomic.LoadInt64(&d.written)
 }
 
 func (d *responseWriterDecorator) StatusCode() int {
+	if d.statusCode == 0 {
+		return http.StatusOK
+	}
 	return d.statusCode
 }
 
+type hijackerDecorator struct {
+	d *responseWriterDecorator
+}
+
+func (h hijackerDecorator) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.d.w.(http.Hijacker).Hijack()
+	if err == nil && h.d.onHijack != nil {
+		h.d.onHijack()
+	}
+	return conn, rw, err
+}
+
+type readerFromDecorator struct {
+	d *responseWriterDecorator
+}
+
+// ReadFrom captures the beginning of the payload and hands the rest over to the underlying writer,
+// preserving its fast path, e.g. sendfile for http.ServeFile
+func (rf readerFromDecorator) ReadFrom(src io.Reader) (int64, error) {
+	var limit int
+	rf.d.mu.Lock()
+	if rf.d.capture != nil {
+		limit = rf.d.capture.limit
+	}
+	rf.d.mu.Unlock()
+	if limit < 0 {
+		return io.Copy(rf.d, src)
+	}
+
+	var n int64
+	if limit > 0 {
+		var err error
+		n, err = io.CopyN(rf.d, src, int64(limit))
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+
+	rf.d.mu.Lock()
+	defer rf.d.mu.Unlock()
+	m, err := rf.d.w.(io.ReaderFrom).ReadFrom(src)
+	rf.d.markWrite()
+	atomic.AddInt64(&rf.d.written, m)
+	if m > 0 {
+		if rf.d.capture != nil {
+			rf.d.capture.truncated = true
+		}
+		rf.d.tail.lost = true
+	}
+	return n + m, err
+}
+
+type pusherDecorator struct {
+	d *responseWriterDecorator
+}
+
+func (p pusherDecorator) Push(target string, opts *http.PushOptions) error {
+	return p.d.w.(http.Pusher).Push(target, opts)
+}
+
 type requestBodyDecorator struct {
-	bodyBytes []byte
-	body      io.ReadCloser
+	buff captureBuffer
+	body io.ReadCloser
+	read int64
+	tail streamTail
+	eof  bool
+	// onFirstRead is called once the first body byte is read by the handler
+	onFirstRead func()
+	// multipart follows the parts of a multipart body, whose raw payload is not captured
+	multipart *multipartScanner
 }
 
-func decorateRequestBody(r *http.Request) *requestBodyDecorator {
+func decorateRequestBody(r *http.Request, captureLimit int) *requestBodyDecorator {
 	if r.Body == nil {
 		return nil
 	}
 
-	return &requestBodyDecorator{
-		body: r.Body,
+	d := requestBodyDecoratorPool.Get().(*requestBodyDecorator)
+	d.buff.limit = captureLimit
+	d.body = r.Body
+	return d
+}
+
+// releaseRequestBody restores the original body of the request and returns the decorator to the pool,
+// it must not be used afterwards
+func releaseRequestBody(r *http.Request, d *requestBodyDecorator) {
+	if d == nil {
+		return
 	}
+	if r.Body == d {
+		r.Body = d.body
+	}
+	if !d.buff.reset() {
+		return
+	}
+	d.body = nil
+	d.read = 0
+	d.tail.reset()
+	d.eof = false
+	d.onFirstRead = nil
+	d.multipart = nil
+	requestBodyDecoratorPool.Put(d)
 }
 
 func (d *requestBodyDecorator) Read(p []byte) (int, error) {
 	n, err := d.body.Read(p)
-	for i := 0; i < n; i++ {
-		d.bodyBytes = append(d.bodyBytes, p[i])
+	if n > 0 && d.read == 0 && d.onFirstRead != nil {
+		d.onFirstRead()
+	}
+	_, _ = d.buff.Write(p[:n])
+	if d.multipart != nil {
+		d.multipart.Write(p[:n])
+	}
+	d.tail.Write(p[:n])
+	d.read += int64(n)
+	if err == io.EOF {
+		d.eof = true
 	}
 	return n, err
 }
@@ -75,5 +482,15 @@ func (d *requestBodyDecorator) Close() error {
 }
 
 func (d *requestBodyDecorator) Payload() []byte {
-	return d.bodyBytes
+	return d.buff.Bytes()
+}
+
+// PayloadTruncated tells whether more bytes were read than captured
+func (d *requestBodyDecorator) PayloadTruncated() bool {
+	return d.buff.Truncated()
+}
+
+// BytesRead returns the number of request body bytes read, regardless of how many were captured
+func (d *requestBodyDecorator) BytesRead() int64 {
+	return d.read
 }
@@ -0,0 +1,489 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_hijack(t *testing.T) {
+	exporter := registerTestExporter()
+
+	spansAtHijack := make(chan int, 1)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("Expected the response writer to implement http.Hijacker")
+			return
+		}
+
+		conn, rw, err := h.Hijack()
+		if err != nil {
+			t.Errorf("Expected the hijack to succeed, while it failed with: %s", err)
+			return
+		}
+		defer conn.Close()
+
+		spansAtHijack <- len(exporter.collected)
+
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected to connect to the server, while it failed with: %s", err)
+	}
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Expected to read the upgrade response, while it failed with: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected the response status code to be '%d'", http.StatusSwitchingProtocols)
+	}
+
+	expectedNumberOfSpans := 1
+	if spans := <-spansAtHijack; spans != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s) at hijack time, while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			spans,
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "[GET] /ws"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			spanData.Name,
+		)
+	}
+
+	if spanData.Attributes["http.hijacked"] != true {
+		t.Fatal("Expected the span attribute of name 'http.hijacked' to have value 'true'")
+	}
+}
+
+func TestComposeResponseWriter_optional_interfaces(t *testing.T) {
+	w := composeResponseWriter(decorateResponseWriter(httptest.NewRecorder(), defaultPayloadSizeLimit))
+
+	if _, ok := w.(http.Flusher); !ok {
+		t.Fatal("Expected the response writer to implement http.Flusher")
+	}
+
+	if _, ok := w.(http.Hijacker); ok {
+		t.Fatal("Expected the response writer not to implement http.Hijacker")
+	}
+
+	if _, ok := w.(http.Pusher); ok {
+		t.Fatal("Expected the response writer not to implement http.Pusher")
+	}
+
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Fatal("Expected the response writer not to implement io.ReaderFrom")
+	}
+
+	w = composeResponseWriter(decorateResponseWriter(&readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}, defaultPayloadSizeLimit))
+
+	if _, ok := w.(io.ReaderFrom); !ok {
+		t.Fatal("Expected the response writer to implement io.ReaderFrom")
+	}
+}
+
+func TestOpencensusTracing_read_from(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadSizeLimit(8)))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		// the reader is wrapped to hide its io.WriterTo, so io.Copy uses ReadFrom
+		_, _ = io.Copy(w, struct{ io.Reader }{strings.NewReader("RESPONSE PAYLOAD")})
+	})
+
+	w := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "RESPONSE PAYLOAD" {
+		t.Fatal("Expected the whole payload to be written")
+	}
+
+	expectedReadFromBytes := int64(len(" PAYLOAD"))
+	if w.readFrom != expectedReadFromBytes {
+		t.Fatalf("Expected %d bytes to be written by the underlying ReadFrom, while it was %d", expectedReadFromBytes, w.readFrom)
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	expectedParameterName := "response_payload"
+	expectedParameterAttribute := "RESPONSE"
+	if exporter.collected[0].Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+}
+
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom int64
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	var buff bytes.Buffer
+	n, err := buff.ReadFrom(src)
+	r.readFrom += n
+	_, _ = r.ResponseRecorder.Write(buff.Bytes())
+	return n, err
+}
+
+func TestComposeResponseWriter_unwrap(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := composeResponseWriter(decorateResponseWriter(&readerFromRecorder{ResponseRecorder: recorder}, defaultPayloadSizeLimit))
+
+	u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+	if !ok {
+		t.Fatal("Expected the response writer to implement Unwrap")
+	}
+
+	unwrapped, ok := u.Unwrap().(*readerFromRecorder)
+	if !ok || unwrapped.ResponseRecorder != recorder {
+		t.Fatal("Expected Unwrap to return the underlying response writer")
+	}
+}
+
+func TestOpencensusTracing_streaming_detection(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadSizeLimit(NoPayloadSizeLimit), WithStreamingDetection()))
+
+	event := []byte("data: event\n\n")
+	numberOfEvents := 100
+
+	r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < numberOfEvents; i++ {
+			_, _ = w.Write(event)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.Len() != len(event)*numberOfEvents {
+		t.Fatal("Expected the whole stream to be written")
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	payload, _ := exporter.collected[0].Attributes["response_payload"].(string)
+	if len(payload) != defaultPayloadSizeLimit+len(payloadTruncatedMessage) {
+		t.Fatalf("Expected the captured payload to be capped at %d bytes, while it was %d", defaultPayloadSizeLimit, len(payload))
+	}
+
+	if !strings.HasSuffix(payload, payloadTruncatedMessage) {
+		t.Fatal("Expected the captured payload to be marked as truncated")
+	}
+}
+
+func TestOpencensusTracing_flush_events(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithFlushEvents()))
+
+	event := []byte("data: event\n\n")
+	numberOfEvents := 3
+
+	r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < numberOfEvents; i++ {
+			_, _ = w.Write(event)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	// one event per flush, followed by the request received and the response sent ones
+	messageEvents := exporter.collected[0].MessageEvents
+	expectedNumberOfMessageEvents := numberOfEvents + 2
+	if len(messageEvents) != expectedNumberOfMessageEvents {
+		t.Fatalf(
+			"Expected to collect %d message event(s), while there were %d collected",
+			expectedNumberOfMessageEvents,
+			len(messageEvents),
+		)
+	}
+
+	for i := 1; i <= numberOfEvents; i++ {
+		e := messageEvents[i-1]
+		expectedSize := int64(i * len(event))
+		if e.MessageID != int64(i) || e.UncompressedByteSize != expectedSize {
+			t.Fatalf("Expected flush event %d to be sized %d bytes, while it was %d", i, expectedSize, e.UncompressedByteSize)
+		}
+	}
+}
+
+// TestOpencensusTracing_concurrent_writes is meant to be run with the race detector
+func TestOpencensusTracing_concurrent_writes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithFlushEvents(), WithPayloadSizeLimit(NoPayloadSizeLimit)))
+
+	event := []byte("data: event\n\n")
+	numberOfWriters, numberOfEvents := 8, 16
+
+	r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < numberOfWriters; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.WriteHeader(http.StatusOK)
+				for j := 0; j < numberOfEvents; j++ {
+					_, _ = w.Write(event)
+					w.(http.Flusher).Flush()
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	expectedPayload := strings.Repeat(string(event), numberOfWriters*numberOfEvents)
+	if w.Body.String() != expectedPayload {
+		t.Fatalf("Expected the response to have %d bytes, while it had %d", len(expectedPayload), w.Body.Len())
+	}
+	if exporter.collected[0].Attributes[spanResponsePayloadAttributeKey] != expectedPayload {
+		t.Fatalf("Expected the whole response payload to be captured")
+	}
+}
+
+func TestResponseWriterDecorator_bytes_written(t *testing.T) {
+	d := decorateResponseWriter(httptest.NewRecorder(), 4)
+	w := composeResponseWriter(d)
+
+	_, _ = w.Write([]byte("RESPONSE"))
+	_, _ = io.Copy(w, struct{ io.Reader }{strings.NewReader(" PAYLOAD")})
+
+	expectedBytesWritten := int64(len("RESPONSE PAYLOAD"))
+	if d.BytesWritten() != expectedBytesWritten {
+		t.Fatalf("Expected %d bytes to be counted, while it was %d", expectedBytesWritten, d.BytesWritten())
+	}
+}
+
+func TestResponseWriterDecorator_counting_without_capture(t *testing.T) {
+	recorder := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	d := decorateResponseWriter(recorder, 0)
+	defer releaseResponseWriter(d)
+	w := composeResponseWriter(d)
+
+	if d.capture != nil {
+		t.Fatal("Expected no capture buffer to be allocated")
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte("RESPONSE"))
+	_, _ = w.(io.ReaderFrom).ReadFrom(strings.NewReader(" PAYLOAD"))
+
+	expectedBytesWritten := int64(len("RESPONSE PAYLOAD"))
+	if d.BytesWritten() != expectedBytesWritten {
+		t.Fatalf("Expected %d bytes to be counted, while it was %d", expectedBytesWritten, d.BytesWritten())
+	}
+	if recorder.readFrom != int64(len(" PAYLOAD")) {
+		t.Fatalf("Expected the payload to be handed over to the underlying writer, while %d bytes were", recorder.readFrom)
+	}
+	if d.StatusCode() != http.StatusCreated {
+		t.Fatalf("Expected the status code %d to be tracked, while it was %d", http.StatusCreated, d.StatusCode())
+	}
+	if d.Payload() != nil || !d.PayloadTruncated() {
+		t.Fatalf("Expected no payload to be captured, while it was '%s'", d.Payload())
+	}
+}
+
+func TestOpencensusTracing_content_length_without_capture(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithRouteOverride("/uploads", RouteConfig{PayloadCapture: false})))
+	r.Post("/uploads", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("RESPONSE"))
+	})
+
+	req, _ := http.NewRequest("POST", "/uploads", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	attributes := exporter.collected[0].Attributes
+	if attributes[spanResponseContentLengthAttributeKey] != int64(len("RESPONSE")) {
+		t.Fatalf("Expected the response content length to be recorded, while it was '%v'", attributes[spanResponseContentLengthAttributeKey])
+	}
+	if attributes[spanStatusCodeAttributeKey] != int64(http.StatusCreated) {
+		t.Fatalf("Expected the status code to be recorded, while it was '%v'", attributes[spanStatusCodeAttributeKey])
+	}
+	if _, ok := attributes[spanResponsePayloadAttributeKey]; ok {
+		t.Fatal("Expected the response payload not to be recorded")
+	}
+}
+
+func TestRequestBodyDecorator_capture_limit(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/test", strings.NewReader("REQUEST PAYLOAD"))
+	d := decorateRequestBody(req, 7)
+
+	body, _ := io.ReadAll(d)
+
+	if string(body) != "REQUEST PAYLOAD" {
+		t.Fatal("Expected the whole body to be read")
+	}
+
+	if string(d.Payload()) != "REQUEST" {
+		t.Fatalf("Expected the captured payload to be 'REQUEST', while it was '%s'", d.Payload())
+	}
+
+	if !d.PayloadTruncated() {
+		t.Fatal("Expected the captured payload to be marked as truncated")
+	}
+
+	expectedBytesRead := int64(len("REQUEST PAYLOAD"))
+	if d.BytesRead() != expectedBytesRead {
+		t.Fatalf("Expected %d bytes to be counted, while it was %d", expectedBytesRead, d.BytesRead())
+	}
+}
+
+func TestReleaseDecorators(t *testing.T) {
+	original := ioutil.NopCloser(strings.NewReader("REQUEST PAYLOAD"))
+	req, _ := http.NewRequest("POST", "/test", nil)
+	req.Body = original
+
+	body := decorateRequestBody(req, NoPayloadSizeLimit)
+	req.Body = body
+	_, _ = io.ReadAll(req.Body)
+
+	releaseRequestBody(req, body)
+
+	if req.Body != original {
+		t.Fatal("Expected the original request body to be restored")
+	}
+
+	ww := decorateResponseWriter(httptest.NewRecorder(), 4)
+	ww.WriteHeader(http.StatusTeapot)
+	_, _ = ww.Write([]byte("RESPONSE"))
+
+	releaseResponseWriter(ww)
+
+	if len(ww.Payload()) != 0 || ww.PayloadTruncated() || ww.BytesWritten() != 0 {
+		t.Fatal("Expected the released response writer decorator to be reset")
+	}
+
+	if ww.StatusCode() != http.StatusOK {
+		t.Fatal("Expected the released response writer decorator to have no status code")
+	}
+}
+
+func BenchmarkResponseWriterDecorator_Write(b *testing.B) {
+	payload := bytes.Repeat([]byte("A"), 1<<10)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Body.Reset()
+		d := decorateResponseWriter(w, NoPayloadSizeLimit)
+		_, _ = d.Write(payload)
+		releaseResponseWriter(d)
+	}
+}
+
+func BenchmarkRequestBodyDecorator_Read(b *testing.B) {
+	payload := bytes.Repeat([]byte("A"), 1<<20)
+
+	for _, limit := range []int{defaultPayloadSizeLimit, NoPayloadSizeLimit} {
+		b.Run(fmt.Sprintf("limit_%d", limit), func(b *testing.B) {
+			b.SetBytes(int64(len(payload)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req, _ := http.NewRequest("POST", "/test", bytes.NewReader(payload))
+				d := decorateRequestBody(req, limit)
+				_, _ = io.Copy(ioutil.Discard, d)
+				releaseRequestBody(req, d)
+			}
+		})
+	}
+}
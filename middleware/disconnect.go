@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanClientDisconnectedAttributeKey      = "http.client_disconnected"
+	spanClientDisconnectedAfterAttributeKey = "http.client_disconnected_after_ms"
+	clientDisconnectedStatusMessage         = "Client disconnected"
+)
+
+// isClientDisconnected tells whether the client went away before the handler wrote the response,
+// i.e. the request context is canceled, rather than timed out, and nothing has been written yet
+func isClientDisconnected(ctxErr error, w *responseWriterDecorator) bool {
+	return errors.Is(ctxErr, context.Canceled) && w.firstWrite.IsZero()
+}
+
+// setSpanClientDisconnected marks the span of a request aborted by the client, following the 499 status code
+// convention of nginx, with the time elapsed until the disconnection is noticed, telling aborted requests
+// apart from server errors. The Cancelled status is set unless an error of the handler is recorded.
+func setSpanClientDisconnected(span *trace.Span, state *requestState, cfg *config) {
+	span.AddAttributes(
+		trace.Int64Attribute(cfg.attributeKeys.statusCode, statusCodeClientClosedRequest),
+		trace.BoolAttribute(spanClientDisconnectedAttributeKey, true),
+		trace.Float64Attribute(spanClientDisconnectedAfterAttributeKey, durationMillis(state.elapsed())),
+	)
+	if state.err == nil {
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeCancelled,
+			Message: clientDisconnectedStatusMessage,
+		})
+	}
+}
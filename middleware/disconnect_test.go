@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_client_disconnected(t *testing.T) {
+	tests := []struct {
+		name                       string
+		handler                    http.HandlerFunc
+		expectedStatusCode         int32
+		expectedHTTPStatusCode     int64
+		expectedClientDisconnected bool
+	}{
+		{
+			name:                       "disconnected before the response is written",
+			handler:                    func(w http.ResponseWriter, r *http.Request) {},
+			expectedStatusCode:         trace.StatusCodeCancelled,
+			expectedHTTPStatusCode:     499,
+			expectedClientDisconnected: true,
+		},
+		{
+			name: "disconnected once the response is written",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			},
+			expectedStatusCode:     trace.StatusCodeCancelled,
+			expectedHTTPStatusCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(WithErrorStatusThreshold(http.StatusInternalServerError)))
+			r.Get("/test", tt.handler)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			req, _ := http.NewRequestWithContext(ctx, "GET", "/test", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Status.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected the span status code to be %d, while it was %d", tt.expectedStatusCode, spanData.Status.Code)
+			}
+
+			expectedAttributeName := "http.status_code"
+			if spanData.Attributes[expectedAttributeName] != tt.expectedHTTPStatusCode {
+				t.Fatalf("Expected the span attribute of name '%s' to have value '%d'", expectedAttributeName, tt.expectedHTTPStatusCode)
+			}
+
+			for _, name := range []string{"http.client_disconnected", "http.client_disconnected_after_ms"} {
+				if _, ok := spanData.Attributes[name]; ok != tt.expectedClientDisconnected {
+					t.Fatalf("Expected the presence of the span attribute of name '%s' to be %t", name, tt.expectedClientDisconnected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	headerNameContentEncoding = "Content-Encoding"
+
+	spanRequestContentEncodingAttributeKey            = "http.request.content_encoding"
+	spanResponseContentEncodingAttributeKey           = "http.response.content_encoding"
+	spanRequestUncompressedContentLengthAttributeKey  = "http.request_content_length_uncompressed"
+	spanResponseUncompressedContentLengthAttributeKey = "http.response_content_length_uncompressed"
+
+	// gzipMinSize is the size of the gzip header and trailer, the latter ending with the size of the decoded data
+	gzipMinSize = 18
+
+	// maxDecompressedPayloadSize caps the decoded payload without a payload size limit, guarding against gzip bombs
+	maxDecompressedPayloadSize = 1 << 20
+)
+
+// streamTail keeps the last bytes of a stream, i.e. the gzip trailer once the stream is complete
+type streamTail struct {
+	b [4]byte
+	// lost marks the tail as unknown, as some bytes of the stream were not seen
+	lost bool
+}
+
+func (t *streamTail) Write(p []byte) {
+	if len(p) >= len(t.b) {
+		copy(t.b[:], p[len(p)-len(t.b):])
+		return
+	}
+	copy(t.b[:], t.b[len(p):])
+	copy(t.b[len(t.b)-len(p):], p)
+}
+
+func (t *streamTail) reset() {
+	*t = streamTail{}
+}
+
+// messageSizes resolves the uncompressed and compressed sizes of a message of the content encoding,
+// given the number of bytes transferred. The compressed size is 0 if the message is not encoded,
+// while the uncompressed size of an encoded message is 0 unless it can be told from a complete gzip stream.
+func messageSizes(contentEncoding string, transferred int64, tail *streamTail, complete bool) (uncompressed, compressed int64) {
+	encoding := strings.ToLower(strings.TrimSpace(contentEncoding))
+	if encoding == "" || encoding == "identity" {
+		return transferred, 0
+	}
+
+	if encoding == "gzip" && complete && !tail.lost && transferred >= gzipMinSize {
+		uncompressed = int64(binary.LittleEndian.Uint32(tail.b[:]))
+	}
+	return uncompressed, transferred
+}
+
+// WithPayloadDecompression enables decoding gzip encoded request and response payloads before they are recorded,
+// so the payload attributes hold readable data instead of compressed bytes, e.g. of the responses compressed
+// by chi middleware.Compress used after this middleware.
+// Only the captured part of a payload is decoded, up to the payload size limit or 1MB without a limit;
+// the handler is not affected.
+func WithPayloadDecompression() Option {
+	return func(c *config) {
+		c.payloadDecompression = true
+	}
+}
+
+// WithRequestBodyDecompression makes the handler read gzip encoded request bodies decoded.
+// The Content-Encoding header of such requests is removed and their content length becomes unknown.
+// The request payload attribute holds the decoded data then as well.
+func WithRequestBodyDecompression() Option {
+	return func(c *config) {
+		c.requestBodyDecompression = true
+	}
+}
+
+// setSpanContentEncodingAttributes records the content encoding of the encoded request and response payloads,
+// along with their uncompressed size if it can be told, the content length attributes being the transferred sizes
+func setSpanContentEncodingAttributes(span *trace.Span, r *http.Request, body *requestBodyDecorator, w *responseWriterDecorator) {
+	var attrs []trace.Attribute
+	if encoding := r.Header.Get(headerNameContentEncoding); isEncoded(encoding) && body != nil {
+		attrs = append(attrs, trace.StringAttribute(spanRequestContentEncodingAttributeKey, encoding))
+		if uncompressed, _ := messageSizes(encoding, body.BytesRead(), &body.tail, body.eof); uncompressed > 0 {
+			attrs = append(attrs, trace.Int64Attribute(spanRequestUncompressedContentLengthAttributeKey, uncompressed))
+		}
+	}
+	if encoding := w.Header().Get(headerNameContentEncoding); isEncoded(encoding) {
+		attrs = append(attrs, trace.StringAttribute(spanResponseContentEncodingAttributeKey, encoding))
+		if uncompressed, _ := messageSizes(encoding, w.BytesWritten(), &w.tail, true); uncompressed > 0 {
+			attrs = append(attrs, trace.Int64Attribute(spanResponseUncompressedContentLengthAttributeKey, uncompressed))
+		}
+	}
+	if len(attrs) > 0 {
+		span.AddAttributes(attrs...)
+	}
+}
+
+func isEncoded(contentEncoding string) bool {
+	encoding := strings.TrimSpace(contentEncoding)
+	return encoding != "" && !strings.EqualFold(encoding, "identity")
+}
+
+func isGzip(contentEncoding string) bool {
+	return strings.EqualFold(strings.TrimSpace(contentEncoding), "gzip")
+}
+
+// decompressPayload decodes the captured gzip payload, up to the limit or 1MB if lower, for recording purposes.
+// A payload which cannot be decoded is returned as it is.
+func decompressPayload(contentEncoding string, payload []byte, limit int, truncated bool) ([]byte, bool) {
+	if !isGzip(contentEncoding) || len(payload) == 0 {
+		return payload, truncated
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return payload, truncated
+	}
+
+	if limit < 0 || limit > maxDecompressedPayloadSize {
+		limit = maxDecompressedPayloadSize
+	}
+	decoded, err := ioutil.ReadAll(io.LimitReader(gz, int64(limit)+1))
+	if err != nil && len(decoded) == 0 {
+		return payload, truncated
+	}
+
+	// an error means only a part of the compressed payload was captured
+	truncated = truncated && err != nil
+	if len(decoded) > limit {
+		decoded, truncated = decoded[:limit], true
+	}
+	return decoded, truncated
+}
+
+// decompressRequestBody replaces the gzip encoded request body with the decoded one
+func decompressRequestBody(r *http.Request) {
+	if r.Body == nil || r.Body == http.NoBody || !isGzip(r.Header.Get(headerNameContentEncoding)) {
+		return
+	}
+	r.Body = &gzipBody{body: r.Body}
+	r.Header.Del(headerNameContentEncoding)
+	r.ContentLength = -1
+}
+
+// gzipBody decodes the body lazily, so no byte is read before the handler asks for it
+type gzipBody struct {
+	body io.ReadCloser
+	gz   *gzip.Reader
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.gz == nil {
+		gz, err := gzip.NewReader(b.body)
+		if err != nil {
+			return 0, err
+		}
+		b.gz = gz
+	}
+	return b.gz.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
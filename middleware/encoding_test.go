@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_compressed_message_events(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := bytes.Repeat([]byte("REQUEST"), 100)
+	compressedReqBody := gzipBytes(reqBody)
+	respBody := bytes.Repeat([]byte("RESPONSE"), 100)
+	compressedRespBody := gzipBytes(respBody)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressedRespBody)
+	})
+
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(compressedReqBody))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedNumberOfMessageEvents := 2
+	if len(spanData.MessageEvents) != expectedNumberOfMessageEvents {
+		t.Fatalf(
+			"Expected to collect %d message event(s), while there were %d collected",
+			expectedNumberOfMessageEvents,
+			len(spanData.MessageEvents),
+		)
+	}
+
+	assertMessageEventSizes(t, spanData.MessageEvents[0], int64(len(reqBody)), int64(len(compressedReqBody)))
+	assertMessageEventSizes(t, spanData.MessageEvents[1], int64(len(respBody)), int64(len(compressedRespBody)))
+}
+
+func TestMessageSizes(t *testing.T) {
+	tests := []struct {
+		name                 string
+		contentEncoding      string
+		complete             bool
+		expectedUncompressed int64
+		expectedCompressed   int64
+	}{
+		{name: "not encoded", contentEncoding: "", complete: true, expectedUncompressed: 20, expectedCompressed: 0},
+		{name: "identity", contentEncoding: "identity", complete: true, expectedUncompressed: 20, expectedCompressed: 0},
+		{name: "gzip", contentEncoding: "gzip", complete: true, expectedUncompressed: 42, expectedCompressed: 20},
+		{name: "incomplete gzip", contentEncoding: "gzip", complete: false, expectedUncompressed: 0, expectedCompressed: 20},
+		{name: "brotli", contentEncoding: "br", complete: true, expectedUncompressed: 0, expectedCompressed: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail := streamTail{}
+			tail.Write([]byte{0xff, 0xff})
+			tail.Write([]byte{42, 0, 0, 0})
+
+			uncompressed, compressed := messageSizes(tt.contentEncoding, 20, &tail, tt.complete)
+			if uncompressed != tt.expectedUncompressed || compressed != tt.expectedCompressed {
+				t.Fatalf(
+					"Expected message sizes to be '%d' and '%d', while they were '%d' and '%d'",
+					tt.expectedUncompressed, tt.expectedCompressed, uncompressed, compressed,
+				)
+			}
+		})
+	}
+}
+
+func TestDecompressPayload_without_limit(t *testing.T) {
+	payload := gzipBytes(make([]byte, 2*maxDecompressedPayloadSize))
+
+	decoded, truncated := decompressPayload("gzip", payload, NoPayloadSizeLimit, false)
+	if len(decoded) != maxDecompressedPayloadSize {
+		t.Fatalf("Expected the decoded payload to be capped at %d bytes, while it had %d", maxDecompressedPayloadSize, len(decoded))
+	}
+	if !truncated {
+		t.Fatal("Expected the decoded payload to be marked as truncated")
+	}
+}
+
+func assertMessageEventSizes(t *testing.T, e trace.MessageEvent, uncompressed, compressed int64) {
+	t.Helper()
+	if e.UncompressedByteSize != uncompressed {
+		t.Fatalf("Expected uncompressed message size to be '%d', while it was '%d'", uncompressed, e.UncompressedByteSize)
+	}
+	if e.CompressedByteSize != compressed {
+		t.Fatalf("Expected compressed message size to be '%d', while it was '%d'", compressed, e.CompressedByteSize)
+	}
+}
+
+func gzipBytes(p []byte) []byte {
+	var buff bytes.Buffer
+	gw := gzip.NewWriter(&buff)
+	_, _ = gw.Write(p)
+	_ = gw.Close()
+	return buff.Bytes()
+}
+
+func TestOpencensusTracing_payload_decompression(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := []byte(`{"request":"payload"}`)
+	compressedReqBody := gzipBytes(reqBody)
+	respBody := bytes.Repeat([]byte("RESPONSE"), 100)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadDecompression(), WithPayloadSizeLimit(64)))
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !bytes.Equal(body, compressedReqBody) {
+			t.Fatal("Expected the handler to read the compressed request body")
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(gzipBytes(respBody))
+	})
+
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(compressedReqBody))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedRequestPayload := string(reqBody)
+	if spanData.Attributes[spanRequestPayloadAttributeKey] != expectedRequestPayload {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", spanRequestPayloadAttributeKey, expectedRequestPayload)
+	}
+
+	expectedResponsePayload := truncatePayload(string(respBody), 64, true)
+	if spanData.Attributes[spanResponsePayloadAttributeKey] != expectedResponsePayload {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", spanResponsePayloadAttributeKey, expectedResponsePayload)
+	}
+}
+
+func TestOpencensusTracing_chi_compress(t *testing.T) {
+	exporter := registerTestExporter()
+
+	respBody := bytes.Repeat([]byte("RESPONSE"), 100)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadDecompression(), WithPayloadSizeLimit(NoPayloadSizeLimit)))
+	r.Use(chimiddleware.Compress(5))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(respBody)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	attributes := exporter.collected[0].Attributes
+	expectedAttributes := map[string]interface{}{
+		spanResponsePayloadAttributeKey:                   string(respBody),
+		spanResponseContentEncodingAttributeKey:           "gzip",
+		spanResponseContentLengthAttributeKey:             int64(w.Body.Len()),
+		spanResponseUncompressedContentLengthAttributeKey: int64(len(respBody)),
+	}
+	for key, expected := range expectedAttributes {
+		if attributes[key] != expected {
+			t.Fatalf("Expected the span attribute '%s' to be '%v', while it was '%v'", key, expected, attributes[key])
+		}
+	}
+	if _, ok := attributes[spanRequestContentEncodingAttributeKey]; ok {
+		t.Fatal("Expected no request content encoding to be recorded")
+	}
+}
+
+func TestOpencensusTracing_request_body_decompression(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := []byte(`{"request":"payload"}`)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithRequestBodyDecompression()))
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !bytes.Equal(body, reqBody) {
+			t.Fatal("Expected the handler to read the decoded request body")
+		}
+		if r.Header.Get("Content-Encoding") != "" {
+			t.Fatal("Expected the Content-Encoding header to be removed")
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(gzipBytes(reqBody)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	expectedRequestPayload := string(reqBody)
+	if exporter.collected[0].Attributes[spanRequestPayloadAttributeKey] != expectedRequestPayload {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", spanRequestPayloadAttributeKey, expectedRequestPayload)
+	}
+}
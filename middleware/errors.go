@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanErrorMessageAttributeKey = "error.message"
+	spanErrorTypeAttributeKey    = "error.type"
+	spanErrorChainAttributeKey   = "error.chain"
+)
+
+// SetSpanError records the error on the span of the context, e.g. the request span.
+// The span status is set according to the error and error.message and error.type attributes are added.
+// If the error wraps other errors, the whole chain is recorded as an annotation.
+// An error recorded on the request span takes precedence over the status resolved from the response status code.
+func SetSpanError(ctx context.Context, err error) {
+	span := spanFromContext(ctx)
+	if span == nil || err == nil {
+		return
+	}
+
+	span.SetStatus(errorStatus(err))
+	span.AddAttributes(
+		trace.StringAttribute(spanErrorMessageAttributeKey, err.Error()),
+		trace.StringAttribute(spanErrorTypeAttributeKey, fmt.Sprintf("%T", err)),
+	)
+
+	if chain := errorChain(err); len(chain) > 1 {
+		attrs := make([]trace.Attribute, 0, len(chain))
+		for i, e := range chain {
+			attrs = append(attrs, trace.StringAttribute(
+				fmt.Sprintf("%s.%d", spanErrorChainAttributeKey, i),
+				fmt.Sprintf("%T: %s", e, e),
+			))
+		}
+		span.Annotate(attrs, "error chain")
+	}
+
+	if state := requestStateFromContext(ctx); state != nil {
+		state.err = err
+	}
+}
+
+func errorStatus(err error) trace.Status {
+	code := int32(trace.StatusCodeUnknown)
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = trace.StatusCodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = trace.StatusCodeDeadlineExceeded
+	}
+	return trace.Status{
+		Code:    code,
+		Message: err.Error(),
+	}
+}
+
+func errorChain(err error) []error {
+	var chain []error
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err)
+	}
+	return chain
+}
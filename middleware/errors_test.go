@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestSetSpanError(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		SetSpanError(r.Context(), fmt.Errorf("loading user: %w", os.ErrNotExist))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	if spanData.Status.Code != trace.StatusCodeUnknown {
+		t.Fatalf("Expected the span status to be '%d' despite the successful response", trace.StatusCodeUnknown)
+	}
+
+	expectedAttributes := map[string]interface{}{
+		"error.message": "loading user: file does not exist",
+		"error.type":    "*fmt.wrapError",
+	}
+	for expectedParameterName, expectedParameterAttribute := range expectedAttributes {
+		if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%v'", expectedParameterName, expectedParameterAttribute)
+		}
+	}
+
+	expectedNumberOfAnnotations := 1
+	if len(spanData.Annotations) != expectedNumberOfAnnotations {
+		t.Fatalf("Expected the span to have %d annotation(s), while it had %d", expectedNumberOfAnnotations, len(spanData.Annotations))
+	}
+
+	expectedChainLink := "*errors.errorString: file does not exist"
+	if spanData.Annotations[0].Attributes["error.chain.1"] != expectedChainLink {
+		t.Fatalf("Expected the error chain annotation to contain '%s'", expectedChainLink)
+	}
+}
+
+func TestSetSpanError_context_error(t *testing.T) {
+	exporter := registerTestExporter()
+
+	ctx, span := trace.StartSpan(context.Background(), "testSpan")
+	SetSpanError(ctx, context.DeadlineExceeded)
+	span.End()
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	if exporter.collected[0].Status.Code != trace.StatusCodeDeadlineExceeded {
+		t.Fatalf("Expected the span status to be '%d'", trace.StatusCodeDeadlineExceeded)
+	}
+}
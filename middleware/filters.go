@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// WithIgnoredPaths excludes the requests matching any of the provided patterns from tracing.
+// Patterns follow the path.Match syntax and are matched against both the chi route pattern
+// and the request path, e.g. "/healthz", "/debug/*" or "/users/{id}/avatar".
+func WithIgnoredPaths(patterns ...string) Option {
+	return WithFilter(func(r *http.Request) bool {
+		routePattern := resolveRoutePattern(r)
+		for _, pattern := range patterns {
+			if matchPath(pattern, routePattern) || matchPath(pattern, r.URL.Path) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WithIgnoredMethods excludes the requests of the provided methods from tracing, e.g. "OPTIONS"
+func WithIgnoredMethods(methods ...string) Option {
+	return WithFilter(func(r *http.Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(method, r.Method) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func matchPath(pattern, p string) bool {
+	if p == "" {
+		return false
+	}
+	matched, err := path.Match(pattern, p)
+	return err == nil && matched
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_ignored_paths_and_methods(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithIgnoredPaths("/healthz", "/debug/*", "/users/{id}/avatar"),
+		WithIgnoredMethods("OPTIONS"),
+	))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	}
+	r.Get("/healthz", handler)
+	r.Get("/debug/*", handler)
+	r.Get("/users/{id}/avatar", handler)
+	r.Get("/users/{id}", handler)
+	r.Options("/users/{id}", handler)
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{method: "GET", path: "/healthz"},
+		{method: "GET", path: "/debug/pprof/heap"},
+		{method: "GET", path: "/users/42/avatar"},
+		{method: "OPTIONS", path: "/users/42"},
+		{method: "GET", path: "/users/42"},
+	}
+	for _, request := range requests {
+		req, _ := http.NewRequest(request.method, request.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "[GET] /users/{id}"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			spanData.Name,
+		)
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanForcedExportAttributeKey = "http.forced_export"
+	spanDurationAttributeKey     = "http.duration_ms"
+)
+
+// WithForceExportOnError exports the span of every request failing with a 5xx status or a panic,
+// even if the sampler chose not to sample it, so errors are never lost to probabilistic sampling.
+// Nothing is recorded on an unsampled span, so it is replaced by a sampled span of the same trace and parent,
+// started once the request is handled. The replacing span has the forced export attribute set and
+// records the request duration, but neither the payloads nor the spans started by the handler.
+func WithForceExportOnError() Option {
+	return func(c *config) {
+		c.forceExportOnError = true
+	}
+}
+
+// shouldForceExport tells whether the unsampled span of the request is replaced by an exported one
+func shouldForceExport(statusCode int, rec interface{}, cfg *config) bool {
+	return cfg.forceExportOnError && (rec != nil || statusCode >= http.StatusInternalServerError)
+}
+
+// exportForcedSpan records the request handled under the unsampled span on a sampled span replacing it
+func exportForcedSpan(span *trace.Span, r *http.Request, w *responseWriterDecorator, state *requestState, start time.Time, rec interface{}, cfg *config) {
+	forced := guardSpan(restartSpan(span, r, cfg), cfg)
+
+	setSpanRequestAttributes(forced, r, cfg.attributeKeys)
+	setSpanPeerAttributes(forced, r, cfg)
+	setSpanRequestIDAttribute(forced, r)
+	setSpanHeaderAttributes(forced, r.Header, cfg.requestHeaders, spanRequestHeaderAttributeKeyPrefix)
+	if len(cfg.globalAttributes) > 0 {
+		forced.AddAttributes(cfg.globalAttributes...)
+	}
+	setSpanNameAndURLAttributes(forced, r, w.StatusCode(), cfg)
+	if state.name != "" {
+		forced.SetName(state.name)
+	}
+	setSpanHeaderAttributes(forced, w.Header(), cfg.responseHeaders, spanResponseHeaderAttributeKeyPrefix)
+	if rec != nil {
+		setSpanPanic(forced, rec, cfg)
+	} else {
+		setSpanStatus(forced, w, state, state.contextErr(r.Context()), cfg)
+	}
+	forced.AddAttributes(
+		trace.BoolAttribute(spanForcedExportAttributeKey, true),
+		trace.Float64Attribute(spanDurationAttributeKey, durationMillis(cfg.now().Sub(start))),
+	)
+	forced.End()
+}
+
+// restartSpan starts a sampled span of the trace of the unsampled span, with its remote parent if any
+func restartSpan(span *trace.Span, r *http.Request, cfg *config) *trace.Span {
+	startOptions := []trace.StartOption{trace.WithSampler(trace.AlwaysSample())}
+	if cfg.spanKind != trace.SpanKindUnspecified {
+		startOptions = append(startOptions, trace.WithSpanKind(cfg.spanKind))
+	}
+
+	// a parent of the trace without a span ID keeps the trace ID of a root span
+	parent := trace.SpanContext{TraceID: span.SpanContext().TraceID}
+	remote, ok := getSpanContext(r, cfg.propagator)
+	if ok && cfg.parentPolicy != LinkOnly {
+		parent = remote
+	}
+	_, forced := trace.StartSpanWithRemoteParent(r.Context(), "", parent, startOptions...)
+
+	if ok && cfg.parentPolicy != ParentOnly {
+		forced.AddLink(trace.Link{
+			TraceID: remote.TraceID,
+			SpanID:  remote.SpanID,
+			Type:    trace.LinkTypeParent,
+		})
+	}
+	return forced
+}
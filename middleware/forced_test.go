@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_force_export_on_error(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(recoverer)
+	r.Use(OpencensusTracing(WithSampler(trace.NeverSample()), WithForceExportOnError()))
+
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/not-found", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/failing/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	r.Get("/panicking", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	for _, path := range []string{"/ok", "/not-found", "/failing/42", "/panicking"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	tests := []struct {
+		spanName     string
+		expectedCode int32
+	}{
+		{spanName: "[GET] /failing/{id}", expectedCode: trace.StatusCodeUnavailable},
+		{spanName: "[GET] /panicking", expectedCode: trace.StatusCodeInternal},
+	}
+	for i, tt := range tests {
+		spanData := exporter.collected[i]
+		if spanData.Name != tt.spanName {
+			t.Fatalf("Expected to collect a span of name '%s', while the actual name was '%s'", tt.spanName, spanData.Name)
+		}
+		if spanData.Status.Code != tt.expectedCode {
+			t.Fatalf("Expected the span '%s' to have status code %d, while it was %d", tt.spanName, tt.expectedCode, spanData.Status.Code)
+		}
+		if spanData.Attributes[spanForcedExportAttributeKey] != true {
+			t.Fatalf("Expected the span '%s' to have the forced export attribute set", tt.spanName)
+		}
+		if duration, ok := spanData.Attributes[spanDurationAttributeKey].(float64); !ok || duration < 0 {
+			t.Fatalf("Expected the span '%s' to have a non-negative duration in milliseconds", tt.spanName)
+		}
+		if spanData.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("Expected the span '%s' to keep the trace of the request, while it was '%s'", tt.spanName, spanData.TraceID)
+		}
+		if spanData.ParentSpanID.String() != "00f067aa0ba902b7" {
+			t.Fatalf("Expected the span '%s' to keep the parent of the request, while it was '%s'", tt.spanName, spanData.ParentSpanID)
+		}
+	}
+}
+
+func TestOpencensusTracing_force_export_on_error_of_root_span(t *testing.T) {
+	exporter := registerTestExporter()
+
+	var traceID trace.TraceID
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSampler(trace.NeverSample()), WithForceExportOnError()))
+	r.Get("/failing", func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.FromContext(r.Context()).SpanContext().TraceID
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req, _ := http.NewRequest("GET", "/failing", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.collected) != 1 {
+		t.Fatalf("Expected to collect 1 span, while there were %d span(s) collected", len(exporter.collected))
+	}
+	spanData := exporter.collected[0]
+	if spanData.TraceID != traceID {
+		t.Fatalf("Expected the span to keep the trace ID '%s' of the request, while it was '%s'", traceID, spanData.TraceID)
+	}
+	if spanData.ParentSpanID != (trace.SpanID{}) {
+		t.Fatalf("Expected the span to be a root span, while its parent was '%s'", spanData.ParentSpanID)
+	}
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanGraphQLOperationNameAttributeKey = "graphql.operation.name"
+	spanGraphQLOperationTypeAttributeKey = "graphql.operation.type"
+
+	graphQLQueryOperationType = "query"
+)
+
+var (
+	// graphQLQueryFieldRegexp and graphQLOperationNameRegexp match the fields of a GraphQL request payload
+	// cut by the payload size limit
+	graphQLQueryFieldRegexp    = regexp.MustCompile(`"query"\s*:\s*"`)
+	graphQLOperationNameRegexp = regexp.MustCompile(`"operationName"\s*:\s*"([_A-Za-z][_0-9A-Za-z]*)"`)
+	// jsonEscapeReplacer unescapes the whitespaces and quotes of a JSON string, the whitespaces separating
+	// the tokens of a GraphQL document
+	jsonEscapeReplacer = strings.NewReplacer(`\n`, " ", `\r`, " ", `\t`, " ", `\"`, `"`, `\\`, `\`)
+)
+
+// WithGraphQLEndpoint names the spans of the POST requests to the GraphQL endpoint of the provided path
+// after the operation of the request too, e.g. "[POST] /graphql query:GetUser", see GraphQLOperationExtractor
+func WithGraphQLEndpoint(path string) Option {
+	return WithOperationExtractor(GraphQLOperationExtractor(path))
+}
+
+// GraphQLOperationExtractor returns the extractor of the operations of the POST requests to the GraphQL endpoint
+// of the provided path, named after their type and name, e.g. "query:GetUser", or their type only if anonymous,
+// and recorded as the graphql.operation.type and graphql.operation.name attributes.
+// The operation is parsed from the request payload captured up to the payload size limit,
+// so the limit has to fit at least the beginning of the GraphQL documents.
+func GraphQLOperationExtractor(path string) OperationExtractor {
+	return func(r *http.Request, payload []byte) (Operation, bool) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			return Operation{}, false
+		}
+		op, ok := parseGraphQLOperation(payload)
+		if !ok {
+			return Operation{}, false
+		}
+
+		attrs := []trace.Attribute{trace.StringAttribute(spanGraphQLOperationTypeAttributeKey, op.typ)}
+		if op.name != "" {
+			attrs = append(attrs, trace.StringAttribute(spanGraphQLOperationNameAttributeKey, op.name))
+		}
+		return Operation{Name: op.String(), Attributes: attrs}, true
+	}
+}
+
+// graphQLRequest is the payload of a GraphQL request over HTTP
+type graphQLRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+}
+
+// graphQLOperation identifies the operation of a GraphQL request, the name of an anonymous operation is empty
+type graphQLOperation struct {
+	typ  string
+	name string
+}
+
+func (o graphQLOperation) String() string {
+	if o.name == "" {
+		return o.typ
+	}
+	return o.typ + ":" + o.name
+}
+
+// parseGraphQLOperation resolves the operation of a GraphQL request payload, i.e. the operation of the requested name,
+// or the first one of the document if no name is requested. A payload cut by the payload size limit is not valid JSON,
+// its operation is then looked up in the raw payload.
+func parseGraphQLOperation(payload []byte) (graphQLOperation, bool) {
+	var req graphQLRequest
+	if json.Unmarshal(payload, &req) != nil {
+		if loc := graphQLQueryFieldRegexp.FindIndex(payload); loc != nil {
+			req.Query = jsonEscapeReplacer.Replace(jsonStringPrefix(payload[loc[1]:]))
+		}
+		if m := graphQLOperationNameRegexp.FindSubmatch(payload); m != nil {
+			req.OperationName = string(m[1])
+		}
+	}
+
+	for _, op := range graphQLOperations(req.Query) {
+		if req.OperationName == "" || op.name == req.OperationName {
+			return op, true
+		}
+	}
+	return graphQLOperation{}, false
+}
+
+// jsonStringPrefix returns the raw content of the JSON string starting the payload, up to its closing quote if any
+func jsonStringPrefix(payload []byte) string {
+	for i := 0; i < len(payload); i++ {
+		switch payload[i] {
+		case '\\':
+			i++
+		case '"':
+			return string(payload[:i])
+		}
+	}
+	return string(payload)
+}
+
+// graphQLOperations returns the operations defined by a GraphQL document, in order. Only the first token
+// of every top-level definition tells its type, so the fields, arguments, strings, comments and fragments
+// of the document are not mistaken for operations. The query shorthand, e.g. "{ user { id } }", is an anonymous query.
+func graphQLOperations(doc string) []graphQLOperation {
+	var ops []graphQLOperation
+	depth, definition := 0, true
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == '#' || c == '"':
+			i = skipGraphQLIgnored(doc, i)
+			continue
+		case c == '{' || c == '(' || c == '[':
+			if c == '{' && depth == 0 && definition {
+				ops = append(ops, graphQLOperation{typ: graphQLQueryOperationType})
+				definition = false
+			}
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			if depth > 0 {
+				depth--
+			}
+			if c == '}' && depth == 0 {
+				definition = true
+			}
+		case isGraphQLNameStart(c):
+			j := graphQLNameEnd(doc, i)
+			if depth == 0 && definition {
+				definition = false
+				if typ := doc[i:j]; typ == "query" || typ == "mutation" || typ == "subscription" {
+					op := graphQLOperation{typ: typ}
+					if k := skipGraphQLIgnored(doc, j); k < len(doc) && isGraphQLNameStart(doc[k]) {
+						j = graphQLNameEnd(doc, k)
+						op.name = doc[k:j]
+					}
+					ops = append(ops, op)
+				}
+			}
+			i = j
+			continue
+		}
+		i++
+	}
+	return ops
+}
+
+// skipGraphQLIgnored returns the index of the first token of the document from i on,
+// skipping whitespaces, commas, comments and strings
+func skipGraphQLIgnored(doc string, i int) int {
+	for i < len(doc) {
+		switch c := doc[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := strings.Index(doc[i+3:], `"""`)
+			if end < 0 {
+				return len(doc)
+			}
+			i += 3 + end + 3
+		case c == '"':
+			for i++; i < len(doc) && doc[i] != '"' && doc[i] != '\n'; i++ {
+				if doc[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		default:
+			return i
+		}
+	}
+	return len(doc)
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// graphQLNameEnd returns the index following the name starting at i
+func graphQLNameEnd(doc string, i int) int {
+	for i < len(doc) && (isGraphQLNameStart(doc[i]) || (doc[i] >= '0' && doc[i] <= '9')) {
+		i++
+	}
+	return i
+}
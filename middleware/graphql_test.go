@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_graphql_operation(t *testing.T) {
+	tests := []struct {
+		name                  string
+		payload               string
+		opts                  []Option
+		expectedSpanName      string
+		expectedOperationType string
+		expectedOperationName string
+	}{
+		{
+			name:                  "named query",
+			payload:               `{"query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":"1"}}`,
+			expectedSpanName:      "[POST] /graphql query:GetUser",
+			expectedOperationType: "query",
+			expectedOperationName: "GetUser",
+		},
+		{
+			name:                  "operation of the requested name",
+			payload:               `{"query":"query GetUser { user { name } }\nmutation DeleteUser { deleteUser }","operationName":"DeleteUser"}`,
+			expectedSpanName:      "[POST] /graphql mutation:DeleteUser",
+			expectedOperationType: "mutation",
+			expectedOperationName: "DeleteUser",
+		},
+		{
+			name:                  "query shorthand",
+			payload:               `{"query":"{ user { name } }"}`,
+			expectedSpanName:      "[POST] /graphql query",
+			expectedOperationType: "query",
+		},
+		{
+			name:                  "payload cut by the payload size limit",
+			payload:               `{"operationName":"CreateUser","query":"mutation CreateUser($input: UserInput!) {\n  createUser(input: $input) { id } }"}`,
+			opts:                  []Option{WithPayloadSizeLimit(64)},
+			expectedSpanName:      "[POST] /graphql mutation:CreateUser",
+			expectedOperationType: "mutation",
+			expectedOperationName: "CreateUser",
+		},
+		{
+			name:                  "operation name too long",
+			payload:               `{"query":"query AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA { user { name } }"}`,
+			expectedSpanName:      "[POST] /graphql",
+			expectedOperationType: "query",
+			expectedOperationName: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		},
+		{
+			name:                  "query shorthand of a field named mutation",
+			payload:               `{"query":"{ mutation { id } }"}`,
+			expectedSpanName:      "[POST] /graphql query",
+			expectedOperationType: "query",
+		},
+		{
+			name:                  "query shorthand of a field named subscription",
+			payload:               `{"query":"{\n  subscription(plan: \"query Plan\") { id }\n}"}`,
+			expectedSpanName:      "[POST] /graphql query",
+			expectedOperationType: "query",
+		},
+		{
+			name:                  "operation following comments and fragments",
+			payload:               `{"query":"# mutation Legacy\nfragment UserFields on User { mutation }\nquery GetUser { user { ...UserFields } }"}`,
+			expectedSpanName:      "[POST] /graphql query:GetUser",
+			expectedOperationType: "query",
+			expectedOperationName: "GetUser",
+		},
+		{
+			name:                  "truncated query shorthand of a field named mutation",
+			payload:               `{"query":"{ mutation(input: \"x\") { id } }","variables":{"input":"` + strings.Repeat("x", 64) + `"}}`,
+			opts:                  []Option{WithPayloadSizeLimit(48)},
+			expectedSpanName:      "[POST] /graphql query",
+			expectedOperationType: "query",
+		},
+		{
+			name:             "not a graphql request",
+			payload:          `{"user":"name"}`,
+			expectedSpanName: "[POST] /graphql",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(append([]Option{WithGraphQLEndpoint("/graphql")}, tt.opts...)...))
+			r.Post("/graphql", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+			})
+
+			req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(tt.payload))
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+			for name, value := range map[string]string{
+				"graphql.operation.type": tt.expectedOperationType,
+				"graphql.operation.name": tt.expectedOperationName,
+			} {
+				if value == "" {
+					if _, ok := spanData.Attributes[name]; ok {
+						t.Fatalf("Expected no span attribute of name '%s'", name)
+					}
+					continue
+				}
+				if spanData.Attributes[name] != value {
+					t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", name, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	handlerSpanName                = "handler"
+	spanMiddlewareTimeAttributeKey = "http.middleware_ms"
+)
+
+// HandlerSpan is a middleware tracing the handler execution in a child span of the request span named "handler",
+// so the time spent in the routing and the middlewares shows apart from the handler on the trace timeline.
+// It has to be the last middleware of the route, e.g. r.With(HandlerSpan).Get("/users/{id}", getUser).
+// The time from the start of the request span is recorded in milliseconds as the http.middleware_ms attribute.
+// The spans started by the handler, e.g. through StartSpan or Transport, are children of the handler span,
+// while the package helpers, e.g. AddAttributes or SetSpanError, keep referring to the request span.
+func HandlerSpan(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := requestStateFromContext(r.Context())
+		if state == nil || !state.span.IsRecordingEvents() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		elapsed := state.elapsed()
+		state.span.AddAttributes(trace.Float64Attribute(spanMiddlewareTimeAttributeKey, durationMillis(elapsed)))
+		ctx, span := trace.StartSpan(r.Context(), handlerSpanName)
+		defer span.End()
+		state.handlerSpan = span
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestHandlerSpan(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+	r.With(HandlerSpan).Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		SetSpanName(r.Context(), "renamed")
+		AddAttributes(r.Context(), trace.StringAttribute("user.id", "42"))
+		_, end := StartSpan(r.Context(), "database")
+		end()
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 3
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	databaseSpan, handlerSpan, requestSpan := exporter.collected[0], exporter.collected[1], exporter.collected[2]
+
+	expectedSpanName := "handler"
+	if handlerSpan.Name != expectedSpanName {
+		t.Fatalf("Expected the span name to be '%s', while it was '%s'", expectedSpanName, handlerSpan.Name)
+	}
+	if handlerSpan.ParentSpanID != requestSpan.SpanID {
+		t.Fatal("Expected the handler span to be a child of the request span")
+	}
+	if databaseSpan.ParentSpanID != handlerSpan.SpanID {
+		t.Fatal("Expected the span started by the handler to be a child of the handler span")
+	}
+	if requestSpan.Attributes["user.id"] != "42" {
+		t.Fatal("Expected the attributes added by the handler to be recorded on the request span")
+	}
+
+	expectedSpanName = "renamed"
+	if requestSpan.Name != expectedSpanName {
+		t.Fatalf("Expected the request span name to be '%s', while it was '%s'", expectedSpanName, requestSpan.Name)
+	}
+
+	expectedAttributeName := "http.middleware_ms"
+	if elapsed, ok := requestSpan.Attributes[expectedAttributeName].(float64); !ok || elapsed < 0 {
+		t.Fatalf("Expected the span attribute of name '%s' to be a non-negative duration", expectedAttributeName)
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanRequestHeaderAttributeKeyPrefix  = "http.request.header."
+	spanResponseHeaderAttributeKeyPrefix = "http.response.header."
+)
+
+// sensitiveHeaders are never recorded as they are, even if explicitly listed
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// propagationHeaders carry the raw opencensus span data between services,
+// they are removed from the requests handed to the handlers if stripped, see WithStripPropagationHeaders
+var propagationHeaders = map[string]bool{
+	propagation.HeaderNameBinary:       true,
+	headerNameOpencensusSpanEventIDKey: true,
+}
+
+// tracingHeaders carry the span data of all the supported formats, they are never recorded, even if explicitly listed
+var tracingHeaders = func() map[string]bool {
+	headers := map[string]bool{headerNameOpencensusSpanEventIDKey: true}
+	for _, name := range propagation.HeaderNames() {
+		headers[name] = true
+	}
+	return headers
+}()
+
+// WithRequestHeaders records the values of the request headers of the provided names
+// as span attributes of the "http.request.header.<name>" key, e.g. "X-Request-Id" or "Content-Type".
+// Multiple values of a header are joined with a comma.
+// Values of sensitive headers, like Authorization or Cookie, are always redacted.
+func WithRequestHeaders(names ...string) Option {
+	return func(c *config) {
+		c.requestHeaders = append(c.requestHeaders, canonicalHeaderNames(names)...)
+	}
+}
+
+// WithResponseHeaders records the values of the response headers of the provided names
+// as span attributes of the "http.response.header.<name>" key, e.g. "Cache-Control" or "X-RateLimit-Remaining".
+// Headers are recorded as set by the handler once the request is handled, following the same rules as WithRequestHeaders.
+func WithResponseHeaders(names ...string) Option {
+	return func(c *config) {
+		c.responseHeaders = append(c.responseHeaders, canonicalHeaderNames(names)...)
+	}
+}
+
+// WithStripPropagationHeaders removes the opencensus propagation headers, i.e. X-Opencensus-Span
+// and X-Opencensus-Event-Id, from the requests handed to the handlers once the span context is extracted,
+// so they are not logged or forwarded downstream by accident. They are preserved by default.
+// The W3C Trace Context and B3 headers are preserved regardless, as other instrumentations may rely on them.
+func WithStripPropagationHeaders() Option {
+	return func(c *config) {
+		c.stripPropagationHeaders = true
+	}
+}
+
+// handlerRequest returns the request handed to the handler, of the provided context,
+// with the propagation headers removed if they are stripped. The headers of the request are left unmodified,
+// as the middleware still reads them once the request is handled.
+func handlerRequest(ctx context.Context, r *http.Request, cfg *config) *http.Request {
+	hr := r.WithContext(ctx)
+	if !cfg.stripPropagationHeaders {
+		return hr
+	}
+
+	found := false
+	for name := range propagationHeaders {
+		if _, ok := r.Header[name]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return hr
+	}
+
+	hr.Header = r.Header.Clone()
+	for name := range propagationHeaders {
+		hr.Header.Del(name)
+	}
+	return hr
+}
+
+func canonicalHeaderNames(names []string) []string {
+	canonical := make([]string, 0, len(names))
+	for _, name := range names {
+		canonical = append(canonical, http.CanonicalHeaderKey(name))
+	}
+	return canonical
+}
+
+func setSpanHeaderAttributes(span *trace.Span, h http.Header, names []string, keyPrefix string) {
+	if len(names) == 0 {
+		return
+	}
+
+	attrs := make([]trace.Attribute, 0, len(names))
+	for _, name := range names {
+		values := h[name]
+		if len(values) == 0 || tracingHeaders[name] {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[name] {
+			value = redactedValue
+		}
+		attrs = append(attrs, trace.StringAttribute(keyPrefix+strings.ToLower(name), value))
+	}
+	span.AddAttributes(attrs...)
+}
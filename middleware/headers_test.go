@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_request_header_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithRequestHeaders("x-request-id", "Accept", "Content-Type", "Authorization")))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-Id", "request-id")
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Accept", "text/plain")
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Cookie", "session=secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedAttributes := map[string]string{
+		"http.request.header.x-request-id":  "request-id",
+		"http.request.header.accept":        "application/json, text/plain",
+		"http.request.header.authorization": "[REDACTED]",
+	}
+	for name, value := range expectedAttributes {
+		if spanData.Attributes[name] != value {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", name, value)
+		}
+	}
+
+	for _, name := range []string{"http.request.header.content-type", "http.request.header.cookie"} {
+		if _, ok := spanData.Attributes[name]; ok {
+			t.Fatalf("Expected no span attribute of name '%s'", name)
+		}
+	}
+}
+
+func TestOpencensusTracing_response_header_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithResponseHeaders("Cache-Control", "X-RateLimit-Remaining", "Set-Cookie")))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedAttributes := map[string]string{
+		"http.response.header.cache-control":         "no-cache",
+		"http.response.header.x-ratelimit-remaining": "42",
+		"http.response.header.set-cookie":            "[REDACTED]",
+	}
+	for name, value := range expectedAttributes {
+		if spanData.Attributes[name] != value {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", name, value)
+		}
+	}
+}
+
+func TestOpencensusTracing_propagation_header_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	names := []string{"traceparent", "tracestate", "b3", "X-B3-TraceId", "x-datadog-trace-id", "X-Amzn-Trace-Id", "grpc-trace-bin"}
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithRequestHeaders(names...)))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	for _, name := range names {
+		req.Header.Set(name, "value")
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spanData := exporter.collected[0]
+	for _, name := range names {
+		key := "http.request.header." + strings.ToLower(name)
+		if _, ok := spanData.Attributes[key]; ok {
+			t.Fatalf("Expected no span attribute of the propagation header '%s'", name)
+		}
+	}
+}
+
+func TestOpencensusTracing_strip_propagation_headers(t *testing.T) {
+	exporter := registerTestExporter()
+
+	var handlerHeader http.Header
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithStripPropagationHeaders(), WithRequestHeaders("X-Opencensus-Span", "X-Opencensus-Event-Id")))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		handlerHeader = r.Header
+	})
+
+	ctx, span := trace.StartSpan(context.Background(), "client")
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req = RequestWithTracingSpan(ctx, req, propagation.Binary())
+	span.End()
+	eID := req.Header.Get("X-Opencensus-Event-Id")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[1]
+
+	if spanData.ParentSpanID != span.SpanContext().SpanID {
+		t.Fatalf("Expected the span to be a child of the client span")
+	}
+	if strconv.FormatInt(spanData.MessageEvents[0].MessageID, 10) != eID {
+		t.Fatalf("Expected the received message event to be of ID '%s', while it was '%d'", eID, spanData.MessageEvents[0].MessageID)
+	}
+
+	for _, name := range []string{"X-Opencensus-Span", "X-Opencensus-Event-Id"} {
+		if handlerHeader.Get(name) != "" {
+			t.Fatalf("Expected the header of name '%s' to be stripped from the request of the handler", name)
+		}
+		if req.Header.Get(name) == "" {
+			t.Fatalf("Expected the header of name '%s' to be preserved on the original request", name)
+		}
+		if _, ok := spanData.Attributes["http.request.header."+strings.ToLower(name)]; ok {
+			t.Fatalf("Expected no span attribute of the propagation header '%s'", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanHeartbeatElapsedAttributeKey = "http.heartbeat.elapsed_ms"
+	spanHeartbeatWrittenAttributeKey = "http.heartbeat.bytes_written"
+)
+
+// WithHeartbeat annotates the spans of the requests lasting longer than the provided interval with a heartbeat
+// annotation every interval, e.g. "Still processing, 30s elapsed, 1.2MB written", so the traces of long-running
+// requests, like exports or streams, show their progress. The annotations are added by a goroutine
+// started for every sampled request, which ends along with the request.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(c *config) {
+		c.heartbeatInterval = interval
+	}
+}
+
+// startHeartbeat starts the goroutine annotating the span with the progress of the request every interval.
+// The heartbeats are due every interval from the start of the request as told by the clock, see WithClock,
+// heartbeats missed while the clock jumps forward are dropped.
+func (s *serverSpan) startHeartbeat() {
+	if s.cfg.heartbeatInterval <= 0 {
+		return
+	}
+	s.heartbeatStop = make(chan struct{})
+	s.heartbeatDone = make(chan struct{})
+
+	go func() {
+		defer close(s.heartbeatDone)
+		interval := s.cfg.heartbeatInterval
+		next := s.start.Add(interval)
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.heartbeatStop:
+				return
+			case <-timer.C:
+			}
+
+			now := s.cfg.now()
+			if elapsed := now.Sub(s.start); !now.Before(next) {
+				s.heartbeat(elapsed)
+				next = next.Add((now.Sub(next)/interval + 1) * interval)
+			}
+			timer.Reset(next.Sub(now))
+		}
+	}()
+}
+
+// stopHeartbeat stops the heartbeat goroutine, waiting for it to end,
+// so the span is not annotated once ended nor the response writer read once released
+func (s *serverSpan) stopHeartbeat() {
+	if s.heartbeatStop == nil {
+		return
+	}
+	close(s.heartbeatStop)
+	<-s.heartbeatDone
+}
+
+func (s *serverSpan) heartbeat(elapsed time.Duration) {
+	precision := time.Second
+	if s.cfg.heartbeatInterval < time.Second {
+		precision = time.Millisecond
+	}
+	written := s.w.BytesWritten()
+
+	s.span.Annotate(
+		[]trace.Attribute{
+			trace.Float64Attribute(spanHeartbeatElapsedAttributeKey, durationMillis(elapsed)),
+			trace.Int64Attribute(spanHeartbeatWrittenAttributeKey, written),
+		},
+		fmt.Sprintf("Still processing, %s elapsed, %s written", elapsed.Round(precision), formatBytes(written)),
+	)
+}
+
+// formatBytes formats the number of bytes in the largest unit it reaches, e.g. 1.2MB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGT"[exp])
+}
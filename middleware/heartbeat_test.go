@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// heartbeatClock is a fake clock counting the times it is read since it was last advanced
+type heartbeatClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	reads int
+}
+
+func (c *heartbeatClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	return c.now
+}
+
+func (c *heartbeatClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.reads = 0
+}
+
+func (c *heartbeatClock) Reads() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reads
+}
+
+func TestOpencensusTracing_heartbeat(t *testing.T) {
+	exporter := registerTestExporter()
+
+	clock := &heartbeatClock{now: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)}
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithHeartbeat(10*time.Millisecond), WithClock(clock.Now)))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, 1536))
+		clock.Advance(25 * time.Millisecond)
+		// the heartbeat is recorded once the goroutine reads the clock again after the advanced read
+		deadline := time.Now().Add(time.Second)
+		for clock.Reads() < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	var heartbeats []string
+	for _, a := range exporter.collected[0].Annotations {
+		if strings.HasPrefix(a.Message, "Still processing") {
+			heartbeats = append(heartbeats, a.Message)
+		}
+	}
+	expectedNumberOfHeartbeats := 1
+	if len(heartbeats) != expectedNumberOfHeartbeats {
+		t.Fatalf("Expected the span to have %d heartbeat annotation(s), while it had %d", expectedNumberOfHeartbeats, len(heartbeats))
+	}
+
+	expectedHeartbeat := "Still processing, 25ms elapsed, 1.5KB written"
+	if heartbeats[0] != expectedHeartbeat {
+		t.Fatalf("Expected the heartbeat annotation to be '%s', while it was '%s'", expectedHeartbeat, heartbeats[0])
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:                    "512B",
+		1536:                   "1.5KB",
+		1258291:                "1.2MB",
+		3 * 1024 * 1024 * 1024: "3.0GB",
+	}
+
+	for n, expected := range tests {
+		if formatted := formatBytes(n); formatted != expected {
+			t.Fatalf("Expected %d bytes to be formatted as '%s', while it was '%s'", n, expected, formatted)
+		}
+	}
+}
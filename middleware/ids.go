@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	cryptorand "crypto/rand"
+	"math"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var eventIDs = struct {
+	mu       sync.Mutex
+	generate func() int64
+	// fallback generates the IDs if the system random number generator fails
+	fallback *rand.Rand
+}{
+	fallback: rand.New(rand.NewSource(time.Now().UnixNano())),
+}
+
+// SetEventIDGenerator sets the function generating the IDs of the message events recorded once the span is injected
+// to an outgoing request, e.g. by AddTracingSpanToRequest or Transport, and sent along in the X-Opencensus-Event-Id header.
+// A counter makes the IDs deterministic in tests; nil restores the random IDs generated by default.
+// The trace and span IDs are generated by the opencensus ID generator, see trace.Config.
+func SetEventIDGenerator(generate func() int64) {
+	eventIDs.mu.Lock()
+	eventIDs.generate = generate
+	eventIDs.mu.Unlock()
+}
+
+func generateEventID() int64 {
+	eventIDs.mu.Lock()
+	generate := eventIDs.generate
+	eventIDs.mu.Unlock()
+	if generate != nil {
+		return generate()
+	}
+
+	eID, err := cryptorand.Int(cryptorand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		eventIDs.mu.Lock()
+		defer eventIDs.mu.Unlock()
+		return eventIDs.fallback.Int63()
+	}
+	return eID.Int64()
+}
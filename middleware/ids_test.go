@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestSetEventIDGenerator(t *testing.T) {
+	var next int64
+	SetEventIDGenerator(func() int64 {
+		next++
+		return next
+	})
+	defer SetEventIDGenerator(nil)
+
+	ctx, span := trace.StartSpan(context.Background(), "client")
+	defer span.End()
+
+	for _, expectedEventID := range []string{"1", "2"} {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req = RequestWithTracingSpan(ctx, req)
+		if eID := req.Header.Get("X-Opencensus-Event-Id"); eID != expectedEventID {
+			t.Fatalf("Expected the event ID to be '%s', while it was '%s'", expectedEventID, eID)
+		}
+	}
+}
+
+func TestOpencensusTracing_clock(t *testing.T) {
+	exporter := registerTestExporter()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(10 * time.Millisecond)
+		return now
+	}
+
+	var duration time.Duration
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithClock(clock),
+		WithSpanEndHook(func(_ *trace.Span, _ *http.Request, _ int, d time.Duration) {
+			duration = d
+		}),
+	))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("O"))
+		_, _ = w.Write([]byte("K"))
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spanData := exporter.collected[0]
+
+	expectedAttributes := map[string]float64{
+		"http.response.first_byte_ms": 10,
+		"http.response.last_byte_ms":  20,
+	}
+	for name, value := range expectedAttributes {
+		if spanData.Attributes[name] != value {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%v', while it was '%v'", name, value, spanData.Attributes[name])
+		}
+	}
+
+	expectedDuration := 30 * time.Millisecond
+	if duration != expectedDuration {
+		t.Fatalf("Expected the request duration to be '%s', while it was '%s'", expectedDuration, duration)
+	}
+}
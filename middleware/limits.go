@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.opencensus.io/trace"
+)
+
+const attributeTruncatedMessage = "...[truncated]"
+
+// WithMaxAttributes caps the number of attributes of a request span, the attributes of new keys beyond the cap
+// are dropped, e.g. to bound the spans of routes of many URL params or recorded headers.
+// It applies to the attributes added by the handlers to the request span as well.
+func WithMaxAttributes(n int) Option {
+	return func(c *config) {
+		c.maxAttributes = n
+	}
+}
+
+// WithMaxAttributeValueLength caps the length in bytes of the string attribute values of a request span
+// and of its annotations, the values exceeding the cap are truncated and marked as truncated,
+// e.g. to bound the values of wildcard route params like "/{path:.*}". The payloads are capped as well.
+func WithMaxAttributeValueLength(n int) Option {
+	return func(c *config) {
+		c.maxAttributeValueLength = n
+	}
+}
+
+// WithAttributeDenylist drops the attributes of the provided keys, e.g. "http.user_agent" or the key of a URL param,
+// from the request span and its annotations, whoever adds them
+func WithAttributeDenylist(keys ...string) Option {
+	return func(c *config) {
+		if c.deniedAttributes == nil {
+			c.deniedAttributes = make(map[string]bool, len(keys))
+		}
+		for _, key := range keys {
+			c.deniedAttributes[key] = true
+		}
+	}
+}
+
+// guardedSpan sanitizes the strings recorded on the span and applies the attribute limits of the configuration
+type guardedSpan struct {
+	trace.SpanInterface
+	cfg *config
+
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// guardSpan returns the span guarding the strings recorded on the provided span, if it records anything
+func guardSpan(span *trace.Span, cfg *config) *trace.Span {
+	if !span.IsRecordingEvents() {
+		return span
+	}
+	return trace.NewSpan(&guardedSpan{SpanInterface: span.Internal(), cfg: cfg, keys: map[string]bool{}})
+}
+
+func (s *guardedSpan) AddAttributes(attributes ...trace.Attribute) {
+	attributes = s.guardAttributes(attributes)
+
+	if s.cfg.maxAttributes > 0 {
+		s.mu.Lock()
+		kept := attributes[:0]
+		for _, attr := range attributes {
+			if !s.keys[attr.Key()] && len(s.keys) >= s.cfg.maxAttributes {
+				continue
+			}
+			s.keys[attr.Key()] = true
+			kept = append(kept, attr)
+		}
+		attributes = kept
+		s.mu.Unlock()
+	}
+
+	if len(attributes) > 0 {
+		s.SpanInterface.AddAttributes(attributes...)
+	}
+}
+
+func (s *guardedSpan) Annotate(attributes []trace.Attribute, str string) {
+	s.SpanInterface.Annotate(s.guardAttributes(attributes), sanitizeString(str))
+}
+
+func (s *guardedSpan) Annotatef(attributes []trace.Attribute, format string, a ...interface{}) {
+	s.SpanInterface.Annotate(s.guardAttributes(attributes), sanitizeString(fmt.Sprintf(format, a...)))
+}
+
+func (s *guardedSpan) SetName(name string) {
+	s.SpanInterface.SetName(sanitizeString(name))
+}
+
+// guardAttributes returns a copy of the attributes without the denied ones and with the string values sanitized and capped
+func (s *guardedSpan) guardAttributes(attributes []trace.Attribute) []trace.Attribute {
+	if len(attributes) == 0 {
+		return attributes
+	}
+	guarded := make([]trace.Attribute, 0, len(attributes))
+	for _, attr := range attributes {
+		if s.cfg.deniedAttributes[attr.Key()] {
+			continue
+		}
+		if v, ok := attr.Value().(string); ok {
+			sanitized := sanitizeString(v)
+			if s.cfg.maxAttributeValueLength > 0 && len(sanitized) > s.cfg.maxAttributeValueLength {
+				sanitized = truncateAttributeValue(sanitized, s.cfg.maxAttributeValueLength)
+			}
+			if sanitized != v {
+				attr = trace.StringAttribute(attr.Key(), sanitized)
+			}
+		}
+		guarded = append(guarded, attr)
+	}
+	return guarded
+}
+
+// sanitizeString replaces the invalid UTF-8 sequences of the string with the replacement character
+// and strips the NUL characters, which some exporters reject or render badly
+func sanitizeString(v string) string {
+	if utf8.ValidString(v) && strings.IndexByte(v, 0) < 0 {
+		return v
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(v, string(utf8.RuneError)), "\x00", "")
+}
+
+// truncateAttributeValue cuts the value to the limit, including the truncation marker if it fits,
+// without splitting a UTF-8 encoded character
+func truncateAttributeValue(v string, limit int) string {
+	if limit <= len(attributeTruncatedMessage) {
+		return v[:runeBoundary(v, limit)]
+	}
+	return v[:runeBoundary(v, limit-len(attributeTruncatedMessage))] + attributeTruncatedMessage
+}
+
+// runeBoundary returns the closest index of the string, not greater than n, where a UTF-8 encoded character starts
+func runeBoundary(v string, n int) int {
+	for n > 0 && n < len(v) && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return n
+}
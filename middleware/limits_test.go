@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_attribute_limits(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithMaxAttributeValueLength(24),
+		WithAttributeDenylist("http.user_agent"),
+	))
+	r.Get("/users/{name}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/users/"+strings.Repeat("a", 64), nil)
+	req.Header.Set("User-Agent", "test-agent")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedValue := "aaaaaaaaaa...[truncated]"
+	if spanData.Attributes["name"] != expectedValue {
+		t.Fatalf("Expected the span attribute of name 'name' to have value '%s', while it was '%v'", expectedValue, spanData.Attributes["name"])
+	}
+	if _, ok := spanData.Attributes["http.user_agent"]; ok {
+		t.Fatal("Expected no span attribute of name 'http.user_agent'")
+	}
+}
+
+func TestOpencensusTracing_max_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithMaxAttributes(4)))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		AddAttributes(r.Context(), trace.StringAttribute("custom", "value"))
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spanData := exporter.collected[0]
+
+	expectedNumberOfAttributes := 4
+	if len(spanData.Attributes) != expectedNumberOfAttributes {
+		t.Fatalf("Expected the span to have %d attribute(s), while it had %d", expectedNumberOfAttributes, len(spanData.Attributes))
+	}
+	if _, ok := spanData.Attributes["custom"]; ok {
+		t.Fatal("Expected the attribute beyond the cap to be dropped")
+	}
+}
+
+func TestTruncateAttributeValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		limit    int
+		expected string
+	}{
+		{value: "abcdefghijklmnopqrstuvwxyz", limit: 20, expected: "abcdef...[truncated]"},
+		{value: "żółć-gęślą-jaźń-żółć-gęślą", limit: 18, expected: "żó...[truncated]"},
+		{value: "żółć", limit: 3, expected: "ż"},
+	}
+
+	for _, tt := range tests {
+		if truncated := truncateAttributeValue(tt.value, tt.limit); truncated != tt.expected {
+			t.Fatalf("Expected '%s' cut at %d to be '%s', while it was '%s'", tt.value, tt.limit, tt.expected, truncated)
+		}
+	}
+}
+
+func TestOpencensusTracing_attribute_sanitization(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithRequestHeaders("X-Name")))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		AddAttributes(r.Context(), trace.StringAttribute("custom", "a\x00b\xffc"))
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Name", "na\xc3me")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spanData := exporter.collected[0]
+
+	expectedAttributes := map[string]string{
+		"custom":                     "ab�c",
+		"http.request.header.x-name": "na�me",
+	}
+	for name, value := range expectedAttributes {
+		if spanData.Attributes[name] != value {
+			t.Fatalf("Expected the span attribute of name '%s' to have value %q, while it was %q", name, value, spanData.Attributes[name])
+		}
+	}
+}
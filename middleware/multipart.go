@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanMultipartPartsAttributeKey  = "http.request.multipart.parts"
+	spanMultipartFilesAttributeKey  = "http.request.multipart.files"
+	spanMultipartFieldsAttributeKey = "http.request.multipart.fields"
+	spanMultipartSizeAttributeKey   = "http.request.multipart.size"
+
+	// maxMultipartLineLength is the length above which a line of a multipart body is neither a delimiter nor a part header
+	maxMultipartLineLength = 1 << 10
+	// maxMultipartFieldNames caps the number of distinct field names recorded
+	maxMultipartFieldNames = 32
+)
+
+// multipartBoundary returns the boundary of a multipart/form-data request, or an empty string for other requests
+func multipartBoundary(r *http.Request) string {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return ""
+	}
+	return params["boundary"]
+}
+
+// multipartScanner follows the multipart body read by the handler, counting its parts and gathering their field names,
+// but none of their values. Only the delimiters and the part headers are buffered, line by line.
+type multipartScanner struct {
+	delimiter []byte
+	line      []byte
+	// overflow marks the current line as too long to be a delimiter or a part header
+	overflow bool
+	headers  bool
+	closed   bool
+	parts    int64
+	files    int64
+	fields   []string
+}
+
+func newMultipartScanner(boundary string) *multipartScanner {
+	return &multipartScanner{delimiter: []byte("--" + boundary)}
+}
+
+func (m *multipartScanner) Write(p []byte) {
+	for len(p) > 0 && !m.closed {
+		i := bytes.IndexByte(p, '\n')
+		chunk := p
+		if i >= 0 {
+			chunk = p[:i]
+		}
+		if !m.overflow {
+			if len(m.line)+len(chunk) > maxMultipartLineLength {
+				m.line, m.overflow = m.line[:0], true
+			} else {
+				m.line = append(m.line, chunk...)
+			}
+		}
+		if i < 0 {
+			return
+		}
+		m.endLine()
+		p = p[i+1:]
+	}
+}
+
+// endLine scans the buffered line, which is complete, e.g. the closing delimiter at the end of the body
+func (m *multipartScanner) endLine() {
+	if !m.overflow {
+		m.scanLine(bytes.TrimSuffix(m.line, []byte("\r")))
+	}
+	m.line, m.overflow = m.line[:0], false
+}
+
+func (m *multipartScanner) scanLine(line []byte) {
+	if bytes.HasPrefix(line, m.delimiter) {
+		switch rest := bytes.TrimRight(line[len(m.delimiter):], " \t"); {
+		case len(rest) == 0:
+			m.parts++
+			m.headers = true
+			return
+		case bytes.Equal(rest, []byte("--")):
+			m.closed = true
+			return
+		}
+	}
+	if !m.headers {
+		return
+	}
+	if len(line) == 0 {
+		m.headers = false
+		return
+	}
+
+	i := bytes.IndexByte(line, ':')
+	if i < 0 || !strings.EqualFold(string(bytes.TrimSpace(line[:i])), "Content-Disposition") {
+		return
+	}
+	_, params, err := mime.ParseMediaType(string(line[i+1:]))
+	if err != nil {
+		return
+	}
+	if _, ok := params["filename"]; ok {
+		m.files++
+	}
+	m.addField(params["name"])
+}
+
+func (m *multipartScanner) addField(name string) {
+	if name == "" || len(m.fields) >= maxMultipartFieldNames {
+		return
+	}
+	for _, field := range m.fields {
+		if field == name {
+			return
+		}
+	}
+	m.fields = append(m.fields, name)
+}
+
+// setSpanMultipartAttributes records the parts of the multipart body read by the handler, instead of its raw payload
+func setSpanMultipartAttributes(span *trace.Span, body *requestBodyDecorator) {
+	if body == nil || body.multipart == nil {
+		return
+	}
+	m := body.multipart
+	m.endLine()
+	span.AddAttributes(
+		trace.Int64Attribute(spanMultipartPartsAttributeKey, m.parts),
+		trace.Int64Attribute(spanMultipartFilesAttributeKey, m.files),
+		trace.StringAttribute(spanMultipartFieldsAttributeKey, strings.Join(m.fields, ",")),
+		trace.Int64Attribute(spanMultipartSizeAttributeKey, body.BytesRead()),
+	)
+}
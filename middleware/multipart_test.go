@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func multipartBody(t *testing.T) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("title", "holidays")
+	_ = mw.WriteField("tags", "beach")
+	_ = mw.WriteField("tags", "sea")
+	file, err := mw.CreateFormFile("photo", "photo.jpg")
+	if err != nil {
+		t.Fatalf("Expected the form file to be created, while it failed with: %s", err)
+	}
+	// the file content contains line breaks and a line looking like a delimiter of another boundary
+	_, _ = file.Write(bytes.Repeat([]byte("\x00\xff\r\n--not-the-boundary\r\n"), 1<<14))
+	_ = mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestOpencensusTracing_multipart_upload(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadSizeLimit(NoPayloadSizeLimit)))
+	r.Post("/photos", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Expected the multipart form to be parsed, while it failed with: %s", err)
+		}
+	})
+
+	body, contentType := multipartBody(t)
+	size := int64(body.Len())
+	req, _ := http.NewRequest("POST", "/photos", body)
+	req.Header.Set("Content-Type", contentType)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	attributes := exporter.collected[0].Attributes
+	expectedAttributes := map[string]interface{}{
+		spanMultipartPartsAttributeKey:  int64(4),
+		spanMultipartFilesAttributeKey:  int64(1),
+		spanMultipartFieldsAttributeKey: "title,tags,photo",
+		spanMultipartSizeAttributeKey:   size,
+	}
+	for key, expected := range expectedAttributes {
+		if attributes[key] != expected {
+			t.Fatalf("Expected the span attribute '%s' to be '%v', while it was '%v'", key, expected, attributes[key])
+		}
+	}
+	if _, ok := attributes[spanRequestPayloadAttributeKey]; ok {
+		t.Fatal("Expected the raw multipart payload not to be recorded")
+	}
+}
+
+func TestMultipartScanner_byte_by_byte(t *testing.T) {
+	body, contentType := multipartBody(t)
+	req, _ := http.NewRequest("POST", "/photos", nil)
+	req.Header.Set("Content-Type", contentType)
+
+	m := newMultipartScanner(multipartBoundary(req))
+	for _, b := range body.Bytes() {
+		m.Write([]byte{b})
+	}
+	m.endLine()
+
+	if m.parts != 4 || m.files != 1 || len(m.fields) != 3 || !m.closed {
+		t.Fatalf("Expected 4 parts, 1 file and 3 fields to be scanned, while there were %d parts, %d files and fields %v", m.parts, m.files, m.fields)
+	}
+}
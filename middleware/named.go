@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// OpencensusTracingNamed returns the tracing middleware of a single route or route group, e.g.
+// r.With(OpencensusTracingNamed("checkout.submit")).Post("/checkout", submit), naming the request spans
+// after the provided business-friendly name. The options apply to the route only, e.g. a different payload size limit
+// or sampler. If the request is already traced by a router-level OpencensusTracing middleware,
+// the span of the route replaces the router-level span, which is dropped rather than exported,
+// so the request is still traced by a single span. The options of the router-level middleware are not inherited.
+func OpencensusTracingNamed(name string, opts ...Option) func(next http.Handler) http.Handler {
+	opts = append(opts[:len(opts):len(opts)], WithSpanNameFormatter(func(*http.Request, string) string {
+		return name
+	}))
+	tracing := OpencensusTracing(opts...)
+
+	return func(next http.Handler) http.Handler {
+		traced := tracing(next)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if state := requestStateFromContext(r.Context()); state != nil {
+				state.superseded = true
+				// the span of the route is not a child of the superseded span, which is never exported
+				ctx := trace.NewContext(r.Context(), nil)
+				if state.otelParent != nil {
+					ctx = oteltrace.ContextWithSpan(ctx, state.otelParent)
+				}
+				r = r.WithContext(ctx)
+			}
+			traced.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
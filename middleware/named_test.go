@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracingNamed(t *testing.T) {
+	tests := []struct {
+		name                   string
+		routerSampler          trace.Sampler
+		path                   string
+		expectedSpanName       string
+		expectedRequestPayload string
+	}{
+		{
+			name:                   "named route",
+			routerSampler:          trace.AlwaysSample(),
+			path:                   "/checkout",
+			expectedSpanName:       "checkout.submit",
+			expectedRequestPayload: "0123456789",
+		},
+		{
+			name:                   "named route of a router not sampling",
+			routerSampler:          trace.NeverSample(),
+			path:                   "/checkout",
+			expectedSpanName:       "checkout.submit",
+			expectedRequestPayload: "0123456789",
+		},
+		{
+			name:                   "other route",
+			routerSampler:          trace.AlwaysSample(),
+			path:                   "/cart",
+			expectedSpanName:       "[POST] /cart",
+			expectedRequestPayload: "01234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				_, _ = ioutil.ReadAll(r.Body)
+			}
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(WithSampler(tt.routerSampler), WithPayloadSizeLimit(5)))
+			r.With(OpencensusTracingNamed(
+				"checkout.submit",
+				WithSampler(trace.AlwaysSample()),
+				WithPayloadSizeLimit(NoPayloadSizeLimit),
+			)).Post("/checkout", handler)
+			r.Post("/cart", handler)
+
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString("0123456789"))
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+			if spanData.ParentSpanID != (trace.SpanID{}) {
+				t.Fatalf("Expected the span to be a root span, while its parent was '%s'", spanData.ParentSpanID)
+			}
+			if spanData.Attributes["request_payload"] != tt.expectedRequestPayload {
+				t.Fatalf("Expected the span attribute of name 'request_payload' to have value '%s', while it was '%v'", tt.expectedRequestPayload, spanData.Attributes["request_payload"])
+			}
+		})
+	}
+}
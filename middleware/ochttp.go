@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
+)
+
+// ochttpAttributeKeys are the keys of the span attributes recorded by ochttp.Handler
+var ochttpAttributeKeys = func() *attributeKeys {
+	keys := *openCensusAttributeKeys
+	keys.url = ochttp.URLAttribute
+	return &keys
+}()
+
+// WithOchttpCompatibility makes the request spans consistent with the ones of the services using ochttp.Handler:
+// the server spans are named after the chi route pattern alone, e.g. "/users/{id}", record the ochttp attributes,
+// including the request URL, and map the response status as ochttp.TraceStatus does.
+// Options applied afterwards, e.g. WithSpanNameFormatter or WithStatusMapper, override the compatible behavior.
+func WithOchttpCompatibility() Option {
+	return func(c *config) {
+		c.spanNameFormatter = ochttpSpanNameFormatter
+		c.statusMapper = ochttpStatusMapper
+		c.attributeKeys = ochttpAttributeKeys
+		c.spanKind = trace.SpanKindServer
+	}
+}
+
+func ochttpSpanNameFormatter(_ *http.Request, routePattern string) string {
+	return routePattern
+}
+
+func ochttpStatusMapper(statusCode int) trace.Status {
+	return ochttp.TraceStatus(statusCode, "")
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_ochttp_compatibility(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithOchttpCompatibility()))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	req, _ := http.NewRequest("GET", "/users/42?fields=name", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.collected) != 1 {
+		t.Fatalf("Expected to collect 1 span, while there were %d span(s) collected", len(exporter.collected))
+	}
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "/users/{id}"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf("Expected to collect a span of name '%s', while the actual name was '%s'", expectedSpanName, spanData.Name)
+	}
+	if spanData.SpanKind != trace.SpanKindServer {
+		t.Fatalf("Expected the span to be a server span, while its kind was %d", spanData.SpanKind)
+	}
+
+	expectedAttributes := map[string]interface{}{
+		ochttp.MethodAttribute:     "GET",
+		ochttp.PathAttribute:       "/users/42",
+		ochttp.URLAttribute:        "/users/42?fields=name",
+		ochttp.StatusCodeAttribute: int64(http.StatusAccepted),
+		spanRouteAttributeKey:      "/users/{id}",
+	}
+	for key, expected := range expectedAttributes {
+		if spanData.Attributes[key] != expected {
+			t.Fatalf("Expected the span attribute '%s' to be '%v', while it was '%v'", key, expected, spanData.Attributes[key])
+		}
+	}
+
+	expectedStatus := ochttp.TraceStatus(http.StatusAccepted, "")
+	if spanData.Status != expectedStatus {
+		t.Fatalf("Expected the span status to be '%v', while it was '%v'", expectedStatus, spanData.Status)
+	}
+}
@@ -2,116 +2,501 @@ package middleware
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"fmt"
-	"math"
-	"math/big"
+	"net"
 	"net/http"
+	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
 	"go.opencensus.io/trace"
-	"go.opencensus.io/trace/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 const (
-	headerNameOpencensusSpan           = "X-Opencensus-Span"
-	headerNameOpencensusSpanEventIDKey = "X-Opencensus-Event-ID"
+	headerNameOpencensusSpanEventIDKey = "X-Opencensus-Event-Id"
 	spanRequestPayloadAttributeKey     = "request_payload"
 	spanResponsePayloadAttributeKey    = "response_payload"
-	payloadSizeLimit                   = 256
 	payloadTruncatedMessage            = "...[payload has been truncated]"
+
+	spanMethodAttributeKey     = "http.method"
+	spanPathAttributeKey       = "http.path"
+	spanHostAttributeKey       = "http.host"
+	spanRouteAttributeKey      = "http.route"
+	spanStatusCodeAttributeKey = "http.status_code"
+	spanUserAgentAttributeKey  = "http.user_agent"
+	spanPanicAttributeKey      = "panic"
+	spanStackTraceAttributeKey = "stack_trace"
+	spanHijackedAttributeKey   = "http.hijacked"
+
+	spanRequestContentLengthAttributeKey  = "http.request_content_length"
+	spanResponseContentLengthAttributeKey = "http.response_content_length"
+	spanFirstByteAttributeKey             = "http.response.first_byte_ms"
+	spanLastByteAttributeKey              = "http.response.last_byte_ms"
+	responseStartedAnnotationMessage      = "Response started"
 )
 
-// AddTracingSpanToRequest resolves span data from the provided context and injects it to the request
-func AddTracingSpanToRequest(ctx context.Context, r *http.Request) {
+// AddTracingSpanToRequest resolves span data from the provided context and injects it to the request.
+// The span context is injected in all the formats of the provided propagators at once, along with the baggage
+// carried by the context, see SetBaggage. If none are provided, the formats the middleware handling the request
+// of the context is configured with are used, see WithPropagators, or else the formats of propagation.DefaultChain.
+// It is idempotent, injecting the same span again to a reused request, e.g. to retry it,
+// neither replaces the message event ID nor records another message event.
+//
+// Deprecated: The headers of the provided request are modified in place, which is unsafe for requests shared
+// across goroutines. Use RequestWithTracingSpan instead.
+func AddTracingSpanToRequest(ctx context.Context, r *http.Request, propagators ...propagation.Propagator) {
+	injectTracingSpan(ctx, r, outboundPropagator(ctx, propagators))
+}
+
+// RequestWithTracingSpan returns a clone of the provided request, of the same context,
+// with the span data resolved from the provided context injected to its headers, as AddTracingSpanToRequest does.
+// The provided request is left unmodified.
+func RequestWithTracingSpan(ctx context.Context, r *http.Request, propagators ...propagation.Propagator) *http.Request {
+	r = r.Clone(r.Context())
+	injectTracingSpan(ctx, r, outboundPropagator(ctx, propagators))
+	return r
+}
+
+// injectTracingSpan injects the span data of the context to the headers of the request in place,
+// unless the span has already been injected, see AddTracingSpanToRequest
+func injectTracingSpan(ctx context.Context, r *http.Request, p propagation.Propagator) {
 	span := trace.FromContext(ctx)
 	if span == nil {
 		return
 	}
-	addSpanMessageSentEvent(span, r)
-	setSpanHeader(span.SpanContext(), r)
+	if !isSpanInjected(span, r, p) {
+		addSpanMessageSentEvent(span, r)
+		setSpanHeaders(span.SpanContext(), r, p)
+	}
+	injectBaggage(ctx, r.Header)
 }
 
 // OpencensusTracing implements a simple middleware handler
 // for adding an opencensus tracing span to the request context
-func OpencensusTracing() func(next http.Handler) http.Handler {
+func OpencensusTracing(opts ...Option) func(next http.Handler) http.Handler {
+	cfg := newConfig(opts)
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			ww := decorateResponseWriter(w)
+			if !cfg.shouldTrace(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cfg := cfg.forRoute(r)
 
-			body := decorateRequestBody(r)
-			r.Body = body
+			inFlightSpans.started()
+			defer inFlightSpans.ended()
+
+			start := cfg.now()
+			ctx, span := startSpan(r, cfg)
+			if guarded := guardSpan(span, cfg); guarded != span {
+				span = guarded
+				ctx = trace.NewContext(ctx, span)
+			}
+			if cfg.debugExporter != nil {
+				defer logDebugSpan(span, cfg.debugExporter)()
+			}
+			ctx, state := contextWithRequestState(ctx, span, start, cfg.now)
+			ctx = contextWithRequestBaggage(ctx, r, cfg)
+			if cfg.propagatorConfigured {
+				ctx = context.WithValue(ctx, propagatorKey{}, cfg.propagator)
+			}
+			setSpanResponseHeaders(w, span.SpanContext(), cfg)
+			if cfg.otelTracer != nil {
+				var otelSpan oteltrace.Span
+				ctx, otelSpan = startOtelSpan(ctx, r, span, state, start, cfg)
+				defer endOtelSpan(span, otelSpan, r, state, cfg)
+			}
+
+			if cfg.requestBodyDecompression {
+				decompressRequestBody(r)
+			}
+
+			if !span.IsRecordingEvents() {
+				// nothing recorded on the span would be exported,
+				// so neither the payloads nor the request attributes are captured
+				serveUnrecorded(next, w, handlerRequest(ctx, r, cfg), span, state, start, cfg)
+				return
+			}
+
+			setSpanRequestAttributes(span, r, cfg.attributeKeys)
+			setSpanPeerAttributes(span, r, cfg)
+			setSpanRequestIDAttribute(span, r)
+			setSpanHeaderAttributes(span, r.Header, cfg.requestHeaders, spanRequestHeaderAttributeKeyPrefix)
+			if len(cfg.globalAttributes) > 0 {
+				span.AddAttributes(cfg.globalAttributes...)
+			}
+			cfg.runSpanStartHooks(span, r)
 
-			ctx := r.Context()
-			var span *trace.Span
+			ww := decorateResponseWriter(w, cfg.payloadCaptureLimit())
+			ww.streamingDetection = cfg.streamingDetection
+			ww.now = cfg.now
 
-			parentSpanContext, ok := getSpanContext(r)
-			if ok {
-				ctx, span = trace.StartSpanWithRemoteParent(ctx, "", parentSpanContext)
-				span.AddLink(trace.Link{
-					TraceID:    parentSpanContext.TraceID,
-					SpanID:     parentSpanContext.SpanID,
-					Type:       trace.LinkTypeParent,
-					Attributes: nil,
-				})
-			} else {
-				ctx, span = trace.StartSpan(ctx, "")
+			// the raw payload of a multipart body is not captured, its parts are recorded instead
+			boundary := multipartBoundary(r)
+			captureLimit := cfg.payloadCaptureLimit()
+			if boundary != "" {
+				captureLimit = 0
 			}
+			body := decorateRequestBody(r, captureLimit)
+			if body != nil {
+				r.Body = body
+				if boundary != "" {
+					body.multipart = newMultipartScanner(boundary)
+				}
+			}
+			defer func() {
+				releaseRequestBody(r, body)
+				releaseResponseWriter(ww)
+			}()
 
-			defer closeSpan(span, ww)
-			defer setSpanResponsePayloadAttribute(span, ww)
-			defer setSpanRequestPayloadAttribute(span, body)
-			defer addSpanMessageReceiveEvent(span, r)
-			defer setSpanNameAndURLAttributes(span, r)
+			ss := &serverSpan{
+				span:  span,
+				r:     r,
+				w:     ww,
+				body:  body,
+				state: state,
+				cfg:   cfg,
+				start: start,
+			}
+			ss.startSlowRequestTimer()
+			ss.startHeartbeat()
+			if body != nil && expectsContinue(r) {
+				body.onFirstRead = ss.requestBodyStarted
+			}
+			ww.onHijack = ss.hijacked
+			ww.onFirstWrite = ss.responseStarted
+			if cfg.flushEvents {
+				ww.onFlush = ss.responseFlushed
+			}
 
-			next.ServeHTTP(ww, r.WithContext(ctx))
+			defer func() {
+				if rec := recover(); rec != nil {
+					ss.end(rec)
+					panic(rec)
+				}
+				ss.end(nil)
+			}()
+
+			next.ServeHTTP(composeResponseWriter(ww), handlerRequest(ctx, r, cfg))
 		}
 
 		return http.HandlerFunc(fn)
 	}
 }
 
-func setSpanHeader(sc trace.SpanContext, r *http.Request) {
-	bin := propagation.Binary(sc)
-	b64 := base64.StdEncoding.EncodeToString(bin)
-	r.Header.Set(headerNameOpencensusSpan, b64)
-}
+// serveUnrecorded handles the request of a span recording nothing, tracking the response only if it is needed
+// by the span end hooks, the stats or the forced export of failed requests, see WithForceExportOnError
+func serveUnrecorded(next http.Handler, w http.ResponseWriter, r *http.Request, span *trace.Span, state *requestState, start time.Time, cfg *config) {
+	defer func() {
+		if !state.superseded {
+			span.End()
+		}
+	}()
+	cfg.runSpanStartHooks(span, r)
 
-func getSpanContext(r *http.Request) (sc trace.SpanContext, ok bool) {
-	b64 := r.Header.Get(headerNameOpencensusSpan)
-	if b64 == "" {
-		return trace.SpanContext{}, false
+	if len(cfg.spanEndHooks) == 0 && !cfg.stats && !cfg.forceExportOnError {
+		next.ServeHTTP(w, r)
+		return
 	}
 
-	bin, err := base64.StdEncoding.DecodeString(b64)
-	if err != nil {
-		return trace.SpanContext{}, false
+	ww := decorateResponseWriter(w, 0)
+	ww.now = cfg.now
+	var body *requestBodyDecorator
+	if cfg.stats {
+		body = decorateRequestBody(r, 0)
+		if body != nil {
+			r.Body = body
+		}
 	}
-
-	return propagation.FromBinary(bin)
+	defer func() {
+		if !state.superseded {
+			duration := cfg.now().Sub(start)
+			cfg.runSpanEndHooks(span, r, ww.StatusCode(), duration)
+			if cfg.stats {
+				recordServerStats(r, ww.StatusCode(), bytesRead(body), ww.BytesWritten(), duration)
+			}
+		}
+		releaseRequestBody(r, body)
+		releaseResponseWriter(ww)
+	}()
+	if cfg.forceExportOnError {
+		defer func() {
+			rec := recover()
+			if !state.superseded && shouldForceExport(ww.StatusCode(), rec, cfg) {
+				exportForcedSpan(span, r, ww, state, start, rec, cfg)
+			}
+			if rec != nil {
+				panic(rec)
+			}
+		}()
+	}
+	next.ServeHTTP(composeResponseWriter(ww), r)
 }
 
-func closeSpan(span *trace.Span, w *responseWriterDecorator) {
-	if w.StatusCode() < 400 {
-		span.SetStatus(trace.Status{
-			Code:    trace.StatusCodeOK,
-			Message: "OK",
-		})
+func startSpan(r *http.Request, cfg *config) (context.Context, *trace.Span) {
+	ctx := r.Context()
+	var span *trace.Span
+
+	var startOptions []trace.StartOption
+	if sampler := cfg.resolveSampler(r); sampler != nil {
+		startOptions = append(startOptions, trace.WithSampler(sampler))
+	}
+	if cfg.spanKind != trace.SpanKindUnspecified {
+		startOptions = append(startOptions, trace.WithSpanKind(cfg.spanKind))
+	}
+
+	parentSpanContext, ok := getSpanContext(r, cfg.propagator)
+	if !ok {
+		return trace.StartSpan(ctx, "", startOptions...)
+	}
+
+	if cfg.parentPolicy == LinkOnly {
+		// the remote span context is not trusted, it is only linked to a new root span
+		ctx, span = trace.StartSpan(ctx, "", startOptions...)
 	} else {
-		span.SetStatus(trace.Status{
-			Code:    trace.StatusCodeUnknown,
-			Message: fmt.Sprintf("Response status code: %d", w.StatusCode()),
+		ctx, span = trace.StartSpanWithRemoteParent(ctx, "", parentSpanContext, startOptions...)
+	}
+
+	if cfg.parentPolicy != ParentOnly {
+		span.AddLink(trace.Link{
+			TraceID:    parentSpanContext.TraceID,
+			SpanID:     parentSpanContext.SpanID,
+			Type:       trace.LinkTypeParent,
+			Attributes: nil,
 		})
 	}
-	span.End()
+
+	return ctx, span
+}
+
+func setSpanHeaders(sc trace.SpanContext, r *http.Request, p propagation.Propagator) {
+	p.Inject(sc, r.Header)
+}
+
+func getSpanContext(r *http.Request, p propagation.Propagator) (sc trace.SpanContext, ok bool) {
+	return p.Extract(r.Header)
+}
+
+func propagatorChain(propagators []propagation.Propagator) propagation.Propagator {
+	if len(propagators) == 0 {
+		return propagation.DefaultChain()
+	}
+	return propagation.NewChain(propagators...)
+}
+
+type propagatorKey struct{}
+
+// outboundPropagator resolves the propagator injecting the span context to an outbound request: the provided propagators,
+// or the ones configured on the middleware handling the request of the context, or else propagation.DefaultChain
+func outboundPropagator(ctx context.Context, propagators []propagation.Propagator) propagation.Propagator {
+	if len(propagators) == 0 {
+		if p, ok := ctx.Value(propagatorKey{}).(propagation.Propagator); ok {
+			return p
+		}
+	}
+	return propagatorChain(propagators)
+}
+
+// serverSpan gathers everything needed to complete the request span once the request is handled
+type serverSpan struct {
+	span  *trace.Span
+	r     *http.Request
+	w     *responseWriterDecorator
+	body  *requestBodyDecorator
+	state *requestState
+	cfg   *config
+	start time.Time
+	once  sync.Once
+	// flushes counts the response flushes, numbering their message events
+	flushes int64
+	// slowTimer captures the stack dump of a request reaching the slow request threshold, see WithSlowRequestStackDump
+	slowTimer *time.Timer
+	slowStack atomic.Value
+	// heartbeatStop stops the heartbeat goroutine, which closes heartbeatDone once stopped, see WithHeartbeat
+	heartbeatStop chan struct{}
+	heartbeatDone chan struct{}
+}
+
+// end completes and ends the span, the value of a handler panic is recorded if not nil.
+// The span is ended only once, subsequent calls are no-ops. A span superseded by the span
+// of a route, see OpencensusTracingNamed, is not ended at all, so it is never exported.
+func (s *serverSpan) end(rec interface{}) {
+	s.once.Do(func() {
+		s.stopSlowRequestTimer()
+		s.stopHeartbeat()
+		if s.state.superseded {
+			return
+		}
+		setSpanNameAndURLAttributes(s.span, s.r, s.w.StatusCode(), s.cfg)
+		setSpanOperation(s.span, s.r, s.body, s.cfg)
+		if s.state.name != "" {
+			s.span.SetName(s.state.name)
+		}
+		eID := addSpanMessageReceiveEvent(s.span, s.r, s.body)
+		addSpanMessageResponseEvent(s.span, eID, s.w)
+		setSpanContentLengthAttributes(s.span, s.body, s.w, s.cfg)
+		setSpanContentEncodingAttributes(s.span, s.r, s.body, s.w)
+		setSpanMultipartAttributes(s.span, s.body)
+		setSpanResponseTimingAttributes(s.span, s.start, s.w)
+		if s.shouldRecordPayloads(rec) {
+			if s.body == nil || s.body.multipart == nil {
+				setSpanRequestPayloadAttribute(s.span, s.r, s.body, s.cfg)
+			}
+			setSpanResponsePayloadAttribute(s.span, s.w, s.cfg)
+		}
+		setSpanHeaderAttributes(s.span, s.w.Header(), s.cfg.responseHeaders, spanResponseHeaderAttributeKeyPrefix)
+		if len(s.cfg.responseTrailers) > 0 {
+			setSpanHeaderAttributes(s.span, responseTrailers(s.w.Header()), s.cfg.responseTrailers, spanResponseTrailerAttributeKeyPrefix)
+		}
+		if rec != nil {
+			setSpanPanic(s.span, rec, s.cfg)
+		} else {
+			setSpanStatus(s.span, s.w, s.state, s.state.contextErr(s.r.Context()), s.cfg)
+		}
+		duration := s.cfg.now().Sub(s.start)
+		s.flagSlowRequest(duration)
+		s.cfg.runSpanEndHooks(s.span, s.r, s.w.StatusCode(), duration)
+		if s.cfg.stats {
+			recordServerStats(s.r, s.w.StatusCode(), bytesRead(s.body), s.w.BytesWritten(), duration)
+		}
+		s.span.End()
+	})
+}
+
+// shouldRecordPayloads tells whether the payloads are recorded, i.e. always, unless limited to failed requests
+// or not captured for the route, see WithRouteOverride.
+// A request fails if the handler panics, reports an error or the response status maps to a non-OK span status.
+func (s *serverSpan) shouldRecordPayloads(rec interface{}) bool {
+	if s.cfg.noPayloadCapture {
+		return false
+	}
+	if !s.cfg.payloadsOnErrorOnly {
+		return true
+	}
+	return rec != nil || s.state.err != nil || s.cfg.spanStatus(s.w.StatusCode()).Code != trace.StatusCodeOK
+}
+
+// responseStarted marks the moment the handler starts writing the response on the span timeline
+func (s *serverSpan) responseStarted() {
+	s.span.Annotate(nil, responseStartedAnnotationMessage)
+}
+
+// responseFlushed records the response chunk flushed by the handler,
+// sized by the number of bytes written so far
+func (s *serverSpan) responseFlushed() {
+	s.flushes++
+	s.span.AddMessageSendEvent(s.flushes, s.w.BytesWritten(), 0)
+}
+
+// hijacked ends the span as soon as the connection is taken over by the handler,
+// as the rest of the exchange is not visible to the middleware
+func (s *serverSpan) hijacked() {
+	s.span.AddAttributes(trace.BoolAttribute(spanHijackedAttributeKey, true))
+	s.end(nil)
+}
+
+// setSpanStatus records the response status code and sets the span status, unless set by an error of the handler.
+// A request whose context is done, e.g. by the chi Timeout middleware, is marked as canceled and,
+// if its response status does not tell otherwise, e.g. a 429 of the chi Throttle middleware, gets the status
+// of the context error, i.e. DeadlineExceeded or Cancelled. A request whose client disconnected
+// before the handler wrote the response is recorded with the 499 status code and the Cancelled status instead.
+// If the tracing middleware precedes the Timeout middleware, the deadline of the context of the handler is noticed
+// once the context is passed to the package helpers, e.g. by the HandlerSpan middleware ending the route.
+func setSpanStatus(span *trace.Span, w *responseWriterDecorator, state *requestState, ctxErr error, cfg *config) {
+	if ctxErr != nil {
+		span.AddAttributes(trace.BoolAttribute(spanContextCanceledAttributeKey, true))
+	}
+	if isClientDisconnected(ctxErr, w) {
+		setSpanClientDisconnected(span, state, cfg)
+		return
+	}
+
+	span.AddAttributes(trace.Int64Attribute(cfg.attributeKeys.statusCode, int64(w.StatusCode())))
+	if state.err != nil {
+		return
+	}
+
+	status := cfg.spanStatus(w.StatusCode())
+	if ctxErr != nil && status.Code == trace.StatusCodeOK {
+		status = errorStatus(ctxErr)
+	}
+	span.SetStatus(status)
+}
+
+func setSpanPanic(span *trace.Span, rec interface{}, cfg *config) {
+	message := fmt.Sprintf("panic: %v", rec)
+	span.SetStatus(trace.Status{
+		Code:    trace.StatusCodeInternal,
+		Message: message,
+	})
+
+	attrs := []trace.Attribute{
+		trace.StringAttribute(spanPanicAttributeKey, fmt.Sprint(rec)),
+	}
+	if cfg.panicStackTrace {
+		attrs = append(attrs, trace.StringAttribute(spanStackTraceAttributeKey, string(debug.Stack())))
+	}
+	span.Annotate(attrs, message)
+}
+
+// addSpanMessageReceiveEvent records the request message of the ID sent by the client, returning the ID.
+// The size is the number of body bytes actually read by the handler, recorded as the compressed size
+// if the body is encoded.
+func addSpanMessageReceiveEvent(span *trace.Span, r *http.Request, body *requestBodyDecorator) int64 {
+	var eID int64
+	if eIDString := r.Header.Get(headerNameOpencensusSpanEventIDKey); eIDString != "" {
+		eID, _ = strconv.ParseInt(eIDString, 10, 64)
+	}
+
+	var uncompressed, compressed int64
+	if body != nil {
+		uncompressed, compressed = messageSizes(r.Header.Get(headerNameContentEncoding), body.BytesRead(), &body.tail, body.eof)
+	}
+	span.AddMessageReceiveEvent(eID, uncompressed, compressed)
+	return eID
+}
+
+// addSpanMessageResponseEvent records the response message, of the ID of the request message,
+// sized by the number of bytes actually written, recorded as the compressed size if the response is encoded
+func addSpanMessageResponseEvent(span *trace.Span, eID int64, w *responseWriterDecorator) {
+	uncompressed, compressed := messageSizes(w.Header().Get(headerNameContentEncoding), w.BytesWritten(), &w.tail, true)
+	span.AddMessageSendEvent(eID, uncompressed, compressed)
+}
+
+func setSpanContentLengthAttributes(span *trace.Span, body *requestBodyDecorator, w *responseWriterDecorator, cfg *config) {
+	span.AddAttributes(
+		trace.Int64Attribute(cfg.attributeKeys.requestContentLength, bytesRead(body)),
+		trace.Int64Attribute(cfg.attributeKeys.responseContentLength, w.BytesWritten()),
+	)
+}
+
+// setSpanResponseTimingAttributes records the time from the start of the request to the first and the last write
+// of the response, telling the handler computation apart from the response streaming
+func setSpanResponseTimingAttributes(span *trace.Span, start time.Time, w *responseWriterDecorator) {
+	if w.firstWrite.IsZero() {
+		return
+	}
+	span.AddAttributes(
+		trace.Float64Attribute(spanFirstByteAttributeKey, durationMillis(w.firstWrite.Sub(start))),
+		trace.Float64Attribute(spanLastByteAttributeKey, durationMillis(w.lastWrite.Sub(start))),
+	)
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
 
-func addSpanMessageReceiveEvent(span *trace.Span, r *http.Request) {
-	eIDString := r.Header.Get(headerNameOpencensusSpanEventIDKey)
-	eID, _ := strconv.ParseInt(eIDString, 10, 64)
-	span.AddMessageReceiveEvent(eID, r.ContentLength, 0)
+func bytesRead(body *requestBodyDecorator) int64 {
+	if body == nil {
+		return 0
+	}
+	return body.BytesRead()
 }
 
 func addSpanMessageSentEvent(span *trace.Span, r *http.Request) {
@@ -121,42 +506,144 @@ func addSpanMessageSentEvent(span *trace.Span, r *http.Request) {
 	span.AddMessageSendEvent(eID, r.ContentLength, 0)
 }
 
-func setSpanRequestPayloadAttribute(span *trace.Span, body *requestBodyDecorator) {
-	var payload string
+func setSpanRequestPayloadAttribute(span *trace.Span, r *http.Request, body *requestBodyDecorator, cfg *config) {
+	var payload []byte
+	var truncated bool
 	if body != nil {
-		payload = string(body.Payload())
+		payload, truncated = body.Payload(), body.PayloadTruncated()
+		if cfg.payloadDecompression {
+			payload, truncated = decompressPayload(r.Header.Get(headerNameContentEncoding), payload, cfg.payloadSizeLimit, truncated)
+		}
+		payload = redactPayload(payload, cfg.payloadRedactors)
 	}
-	if len(payload) > payloadSizeLimit {
-		payload = payload[:payloadSizeLimit-len(payloadTruncatedMessage)]
-		payload += payloadTruncatedMessage
+	value := formatPayload(payload, bytesRead(body), truncated, cfg)
+	span.AddAttributes(trace.StringAttribute(spanRequestPayloadAttributeKey, value))
+}
+
+func setSpanResponsePayloadAttribute(span *trace.Span, w *responseWriterDecorator, cfg *config) {
+	payload, truncated := w.Payload(), w.PayloadTruncated()
+	if cfg.payloadDecompression {
+		payload, truncated = decompressPayload(w.Header().Get(headerNameContentEncoding), payload, cfg.payloadSizeLimit, truncated)
 	}
-	span.AddAttributes(trace.StringAttribute(spanRequestPayloadAttributeKey, payload))
+	payload = redactPayload(payload, cfg.payloadRedactors)
+	value := formatPayload(payload, w.BytesWritten(), truncated, cfg)
+	span.AddAttributes(trace.StringAttribute(spanResponsePayloadAttributeKey, value))
 }
 
-func setSpanResponsePayloadAttribute(span *trace.Span, w *responseWriterDecorator) {
-	payload := string(w.Payload())
-	if len(payload) > payloadSizeLimit {
-		payload = payload[:payloadSizeLimit-len(payloadTruncatedMessage)]
-		payload += payloadTruncatedMessage
+// truncatePayload cuts the payload to the limit, marking it as truncated,
+// if it exceeds the limit or if only a part of the original payload was captured
+func truncatePayload(payload string, limit int, truncated bool) string {
+	if !truncated && (limit < 0 || len(payload) <= limit) {
+		return payload
+	}
+	if limit < 0 {
+		return payload + payloadTruncatedMessage
 	}
-	span.AddAttributes(trace.StringAttribute(spanResponsePayloadAttributeKey, payload))
+	if limit <= len(payloadTruncatedMessage) {
+		if len(payload) > limit {
+			return payload[:limit]
+		}
+		return payload
+	}
+	if cut := limit - len(payloadTruncatedMessage); len(payload) > cut {
+		payload = payload[:cut]
+	}
+	return payload + payloadTruncatedMessage
 }
 
-func setSpanNameAndURLAttributes(span *trace.Span, r *http.Request) {
-	rCtx := chi.RouteContext(r.Context())
+func setSpanRequestAttributes(span *trace.Span, r *http.Request, keys *attributeKeys) {
+	attrs := make([]trace.Attribute, 0, 5)
+	attrs = append(attrs,
+		trace.StringAttribute(keys.method, r.Method),
+		trace.StringAttribute(keys.path, r.URL.Path),
+	)
+	host, port := r.Host, ""
+	if keys.hostPort != "" {
+		if h, p, err := net.SplitHostPort(r.Host); err == nil {
+			host, port = h, p
+		}
+	}
+	attrs = append(attrs, trace.StringAttribute(keys.host, host))
+	if p, err := strconv.ParseInt(port, 10, 64); err == nil {
+		attrs = append(attrs, trace.Int64Attribute(keys.hostPort, p))
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		attrs = append(attrs, trace.StringAttribute(keys.userAgent, userAgent))
+	}
+	if keys.url != "" {
+		attrs = append(attrs, trace.StringAttribute(keys.url, r.URL.String()))
+	}
+	span.AddAttributes(attrs...)
+}
 
-	spanName := fmt.Sprintf("[%s] %s", r.Method, rCtx.RoutePattern())
-	span.SetName(spanName)
+func setSpanNameAndURLAttributes(span *trace.Span, r *http.Request, statusCode int, cfg *config) {
+	rCtx, routePattern := chiRoutePattern(r)
+	if routePattern == "" {
+		// outside of chi, e.g. with WrapHandler, or for a request matching no route there is no route pattern,
+		// the URL params, if any, are not the ones of a matched route either
+		span.SetName(cfg.spanNameFormatter(r, unmatchedRoutePattern(r, rCtx, statusCode, cfg)))
+		return
+	}
 
-	for _, key := range rCtx.URLParams.Keys {
-		span.AddAttributes(trace.StringAttribute(key, rCtx.URLParam(key)))
+	span.SetName(cfg.spanNameFormatter(r, routePattern))
+
+	attrs := make([]trace.Attribute, 0, 2+len(rCtx.URLParams.Keys))
+	attrs = append(attrs, trace.StringAttribute(cfg.attributeKeys.route, routePattern))
+	path, pathMasked := r.URL.Path, false
+	for i, key := range rCtx.URLParams.Keys {
+		value := rCtx.URLParams.Values[i]
+		if mask := cfg.urlParamMasker(routePattern, key); mask != nil && value != "" {
+			masked := mask(value)
+			path, pathMasked = maskPath(path, value, masked), true
+			value = masked
+		}
+		attrs = append(attrs, trace.StringAttribute(cfg.urlParamPrefix+key, value))
 	}
+	if pathMasked {
+		attrs = append(attrs, trace.StringAttribute(cfg.attributeKeys.path, path))
+		if cfg.attributeKeys.url != "" {
+			u := *r.URL
+			u.Path, u.RawPath = path, ""
+			attrs = append(attrs, trace.StringAttribute(cfg.attributeKeys.url, u.String()))
+		}
+	}
+	span.AddAttributes(attrs...)
 }
 
-func generateEventID() int64 {
-	eID, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
-	if err != nil {
-		return 0
+// spanName resolves the name of the request span after the chi route pattern,
+// or the URL path if there is none, see setSpanNameAndURLAttributes
+func spanName(r *http.Request, cfg *config) string {
+	if _, routePattern := chiRoutePattern(r); routePattern != "" {
+		return cfg.spanNameFormatter(r, routePattern)
+	}
+	return cfg.spanNameFormatter(r, r.URL.Path)
+}
+
+// unmatchedRoutePattern returns the placeholder of the route pattern of a request matching no chi route,
+// answered with the 404 or 405 status code. Otherwise, e.g. for a NotFound handler serving a single-page application
+// or outside of chi, the URL path is returned.
+func unmatchedRoutePattern(r *http.Request, rCtx *chi.Context, statusCode int, cfg *config) string {
+	if rCtx != nil {
+		switch statusCode {
+		case http.StatusNotFound:
+			return cfg.notFoundRoutePattern
+		case http.StatusMethodNotAllowed:
+			return cfg.methodNotAllowedRoutePattern
+		}
 	}
-	return eID.Int64()
+	return r.URL.Path
+}
+
+// chiRoutePattern returns the chi routing context of the request along with the pattern of the matched route.
+// The context is nil outside of chi, the pattern is empty if no route is matched, e.g. in a NotFound handler.
+func chiRoutePattern(r *http.Request) (*chi.Context, string) {
+	rCtx := chi.RouteContext(r.Context())
+	if rCtx == nil {
+		return nil, ""
+	}
+	return rCtx, fullRoutePattern(rCtx)
+}
+
+func defaultSpanNameFormatter(r *http.Request, routePattern string) string {
+	return "[" + r.Method + "] " + routePattern
 }
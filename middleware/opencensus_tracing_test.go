@@ -3,10 +3,13 @@ package middleware
 import (
 	"bytes"
 	"context"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.opencensus.io/trace"
@@ -52,6 +55,165 @@ func TestOpencensusTracing_open_span(t *testing.T) {
 	}
 }
 
+func TestOpencensusTracing_span_name_formatter(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "http://example.com/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSpanNameFormatter(func(r *http.Request, routePattern string) string {
+		return "orders " + r.Host + routePattern
+	})))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "orders example.com/test"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			spanData.Name,
+		)
+	}
+}
+
+func TestOpencensusTracing_filter(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/healthz"
+	})))
+
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	for _, path := range []string{"/healthz", "/test"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "[GET] /test"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			spanData.Name,
+		)
+	}
+}
+
+func TestOpencensusTracing_sampler(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSampler(trace.NeverSample())))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 0
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+}
+
+func TestOpencensusTracing_not_sampled_request_is_not_decorated(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSampler(trace.NeverSample())))
+
+	body := ioutil.NopCloser(strings.NewReader("REQUEST PAYLOAD"))
+	w := httptest.NewRecorder()
+
+	r.Post("/test", func(hw http.ResponseWriter, hr *http.Request) {
+		if hw != w {
+			t.Fatal("Expected the response writer not to be decorated")
+		}
+		if hr.Body != body {
+			t.Fatal("Expected the request body not to be decorated")
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/test", nil)
+	req.Body = body
+	r.ServeHTTP(w, req)
+}
+
+func TestOpencensusTracing_sampler_func(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSamplerFunc(func(r *http.Request) trace.Sampler {
+		if r.Header.Get("X-Debug-Trace") == "1" {
+			return trace.AlwaysSample()
+		}
+		return trace.NeverSample()
+	})))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	req, _ = http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Debug-Trace", "1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+}
+
 func TestOpencensusTracing_link_to_parent_span(t *testing.T) {
 	exporter := registerTestExporter()
 
@@ -149,6 +311,122 @@ func TestOpencensusTracing_url_params_in_attributes(t *testing.T) {
 	}
 }
 
+func TestOpencensusTracing_url_params_in_prefixed_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test/foo", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithURLParamPrefix(URLParamNamespace)))
+
+	r.Get("/test/{request_payload}", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterName := "chi.param.request_payload"
+	expectedParameterAttribute := "foo"
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+
+	if spanData.Attributes[spanRequestPayloadAttributeKey] != "" {
+		t.Fatalf("Expected the span attribute of name '%s' not to be overridden", spanRequestPayloadAttributeKey)
+	}
+}
+
+func TestOpencensusTracing_http_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "http://example.com/test/foo", nil)
+	req.Header.Set("User-Agent", "test-agent")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test/{param_name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedAttributes := map[string]interface{}{
+		"http.method":      "GET",
+		"http.path":        "/test/foo",
+		"http.host":        "example.com",
+		"http.route":       "/test/{param_name}",
+		"http.status_code": int64(http.StatusAccepted),
+		"http.user_agent":  "test-agent",
+	}
+	for expectedParameterName, expectedParameterAttribute := range expectedAttributes {
+		attribute, attributeSet := spanData.Attributes[expectedParameterName]
+		if !attributeSet {
+			t.Fatalf("Expected the span to have parameter attribute of name '%s' set", expectedParameterName)
+		}
+		if attribute != expectedParameterAttribute {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%v'", expectedParameterName, expectedParameterAttribute)
+		}
+	}
+}
+
+func TestOpencensusTracing_implicit_status_code_attribute(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("RESPONSE"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterName := "http.status_code"
+	expectedParameterAttribute := int64(http.StatusOK)
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%d'", expectedParameterName, expectedParameterAttribute)
+	}
+}
+
 func TestOpencensusTracing_payload_attributes(t *testing.T) {
 	exporter := registerTestExporter()
 
@@ -247,6 +525,306 @@ func TestOpencensusTracing_payload_attributes_no_request_body_no_response_body(t
 	}
 }
 
+func TestOpencensusTracing_payload_attributes_truncated(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := bytes.Repeat([]byte("A"), 300)
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(reqBody))
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterName := "request_payload"
+	expectedParameterAttribute := string(reqBody[:256-len(payloadTruncatedMessage)]) + payloadTruncatedMessage
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+}
+
+func TestOpencensusTracing_payload_attributes_with_payload_size_limit(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := bytes.Repeat([]byte("A"), 300)
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(reqBody))
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadSizeLimit(10)))
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write(reqBody)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterAttribute := "AAAAAAAAAA"
+	for _, expectedParameterName := range []string{"request_payload", "response_payload"} {
+		if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+		}
+	}
+}
+
+func TestOpencensusTracing_payload_attributes_no_payload_size_limit(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := bytes.Repeat([]byte("A"), 300)
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(reqBody))
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPayloadSizeLimit(NoPayloadSizeLimit)))
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterName := "request_payload"
+	expectedParameterAttribute := string(reqBody)
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+}
+
+func TestOpencensusTracing_payload_attributes_on_error_only(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		statusCode       int
+		expectedRecorded bool
+	}{
+		{name: "success", statusCode: http.StatusOK, expectedRecorded: false},
+		{name: "failure", statusCode: http.StatusInternalServerError, expectedRecorded: true},
+		{name: "client error", statusCode: http.StatusNotFound, expectedRecorded: true},
+		{
+			name:             "client error below threshold",
+			opts:             []Option{WithErrorStatusThreshold(http.StatusInternalServerError)},
+			statusCode:       http.StatusNotFound,
+			expectedRecorded: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(append(tt.opts, WithPayloadsOnErrorOnly())...))
+
+			r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("RESPONSE"))
+			})
+
+			req, _ := http.NewRequest("POST", "/test", strings.NewReader("REQUEST"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			for _, name := range []string{spanRequestPayloadAttributeKey, spanResponsePayloadAttributeKey} {
+				if _, recorded := spanData.Attributes[name]; recorded != tt.expectedRecorded {
+					t.Fatalf("Expected the span attribute of name '%s' to be recorded: %t", name, tt.expectedRecorded)
+				}
+			}
+		})
+	}
+}
+
+func TestOpencensusTracing_response_timing(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte("RESPONSE"))
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte("RESPONSE"))
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	firstByte, _ := spanData.Attributes["http.response.first_byte_ms"].(float64)
+	lastByte, _ := spanData.Attributes["http.response.last_byte_ms"].(float64)
+	if firstByte < 10 || lastByte < firstByte+10 {
+		t.Fatalf("Expected the response to start after 10ms and end 10ms later, while it was %fms and %fms", firstByte, lastByte)
+	}
+
+	if len(spanData.Annotations) != 1 || spanData.Annotations[0].Message != responseStartedAnnotationMessage {
+		t.Fatalf("Expected the span to have the annotation '%s'", responseStartedAnnotationMessage)
+	}
+}
+
+func TestOpencensusTracing_global_attributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithGlobalAttributes(trace.StringAttribute("service", "orders")),
+		WithGlobalAttributes(trace.StringAttribute("env", "prod")),
+	))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedAttributes := map[string]string{"service": "orders", "env": "prod"}
+	for name, value := range expectedAttributes {
+		if spanData.Attributes[name] != value {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", name, value)
+		}
+	}
+}
+
+func TestOpencensusTracing_span_hooks(t *testing.T) {
+	tests := []struct {
+		name                  string
+		sampler               trace.Sampler
+		expectedNumberOfSpans int
+	}{
+		{name: "sampled", sampler: trace.AlwaysSample(), expectedNumberOfSpans: 1},
+		{name: "not sampled", sampler: trace.NeverSample(), expectedNumberOfSpans: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			var started, ended int
+			var endStatusCode int
+			var endDuration time.Duration
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(
+				WithSampler(tt.sampler),
+				WithSpanStartHook(func(span *trace.Span, r *http.Request) {
+					started++
+					span.AddAttributes(trace.StringAttribute("hook", "start"))
+				}),
+				WithSpanEndHook(func(span *trace.Span, r *http.Request, statusCode int, duration time.Duration) {
+					ended++
+					endStatusCode, endDuration = statusCode, duration
+				}),
+			))
+
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(time.Millisecond)
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if started != 1 || ended != 1 {
+				t.Fatalf("Expected the hooks to be called once, while they were called %d and %d times", started, ended)
+			}
+
+			if endStatusCode != http.StatusAccepted {
+				t.Fatalf("Expected the end hook to get status code '%d', while it was '%d'", http.StatusAccepted, endStatusCode)
+			}
+
+			if endDuration < time.Millisecond {
+				t.Fatalf("Expected the end hook to get the request duration, while it was %s", endDuration)
+			}
+
+			if len(exporter.collected) != tt.expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					tt.expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+			if tt.expectedNumberOfSpans > 0 && exporter.collected[0].Attributes["hook"] != "start" {
+				t.Fatal("Expected the start hook to add attributes to the span")
+			}
+		})
+	}
+}
+
 func TestOpencensusTracing_message_received_event_added(t *testing.T) {
 	exporter := registerTestExporter()
 
@@ -258,6 +836,7 @@ func TestOpencensusTracing_message_received_event_added(t *testing.T) {
 	r.Use(OpencensusTracing())
 
 	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
 		_, _ = w.Write([]byte("RESPONSE"))
 	})
 
@@ -275,8 +854,8 @@ func TestOpencensusTracing_message_received_event_added(t *testing.T) {
 
 	spanData := exporter.collected[0]
 
-	expectedNumberOfMessageEvents := 1
-	if len(spanData.MessageEvents) != expectedNumberOfSpans {
+	expectedNumberOfMessageEvents := 2
+	if len(spanData.MessageEvents) != expectedNumberOfMessageEvents {
 		t.Fatalf(
 			"Expected to collect %d message event(s), while there were %d collected",
 			expectedNumberOfMessageEvents,
@@ -302,6 +881,25 @@ func TestOpencensusTracing_message_received_event_added(t *testing.T) {
 	if messageEvent.CompressedByteSize != 0 {
 		t.Fatal("Expected message size to be '0'")
 	}
+
+	responseEvent := spanData.MessageEvents[1]
+
+	if responseEvent.EventType != trace.MessageEventTypeSent {
+		t.Fatalf("Expected message type to be '%d'", trace.MessageEventTypeSent)
+	}
+
+	expectedResponseSize := int64(len("RESPONSE"))
+	if responseEvent.UncompressedByteSize != expectedResponseSize {
+		t.Fatalf("Expected message size to be '%d'", expectedResponseSize)
+	}
+
+	if spanData.Attributes["http.request_content_length"] != req.ContentLength {
+		t.Fatalf("Expected the span attribute of name 'http.request_content_length' to have value '%d'", req.ContentLength)
+	}
+
+	if spanData.Attributes["http.response_content_length"] != expectedResponseSize {
+		t.Fatalf("Expected the span attribute of name 'http.response_content_length' to have value '%d'", expectedResponseSize)
+	}
 }
 
 func TestOpencensusTracing_message_sent_event_added(t *testing.T) {
@@ -375,3 +973,77 @@ func registerTestExporter() *exporterMock {
 	})
 	return exporter
 }
+
+func BenchmarkOpencensusTracing(b *testing.B) {
+	largePayload := bytes.Repeat([]byte("A"), 1<<20)
+	event := []byte("data: event\n\n")
+
+	benchmarks := []struct {
+		name    string
+		opts    []Option
+		body    []byte
+		handler http.HandlerFunc
+	}{
+		{
+			name: "sampled",
+			opts: []Option{WithSampler(trace.AlwaysSample())},
+			body: []byte(`{"request":"payload"}`),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = ioutil.ReadAll(r.Body)
+				_, _ = w.Write([]byte(`{"response":"payload"}`))
+			},
+		},
+		{
+			name: "unsampled",
+			opts: []Option{WithSampler(trace.NeverSample())},
+			body: []byte(`{"request":"payload"}`),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = ioutil.ReadAll(r.Body)
+				_, _ = w.Write([]byte(`{"response":"payload"}`))
+			},
+		},
+		{
+			name: "large_body",
+			opts: []Option{WithSampler(trace.AlwaysSample())},
+			body: largePayload,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(w, r.Body)
+			},
+		},
+		{
+			name: "streaming",
+			opts: []Option{
+				WithSampler(trace.AlwaysSample()),
+				WithPayloadSizeLimit(NoPayloadSizeLimit),
+				WithStreamingDetection(),
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for i := 0; i < 100; i++ {
+					_, _ = w.Write(event)
+					w.(http.Flusher).Flush()
+				}
+			},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(bm.opts...))
+			r.Post("/test/{id}", bm.handler)
+
+			body := bytes.NewReader(bm.body)
+			req, _ := http.NewRequest("POST", "/test/1", body)
+			w := httptest.NewRecorder()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				body.Reset(bm.body)
+				w.Body.Reset()
+				r.ServeHTTP(w, req)
+			}
+		})
+	}
+}
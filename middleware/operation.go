@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanRPCSystemAttributeKey         = "rpc.system"
+	spanRPCMethodAttributeKey         = "rpc.method"
+	spanJSONRPCVersionAttributeKey    = "rpc.jsonrpc.version"
+	spanSOAPActionAttributeKey        = "soap.action"
+	jsonRPCSystem                     = "jsonrpc"
+	headerNameSOAPAction              = "SOAPAction"
+	headerNameContentType             = "Content-Type"
+	soapContentTypeActionParameterKey = "action"
+)
+
+// operationNameRegexp bounds the length and the charset of the operation names suffixing the span names
+var operationNameRegexp = regexp.MustCompile(`^[0-9A-Za-z_.:/-]{1,64}$`)
+
+// jsonRPCMethodRegexp matches the method of a JSON-RPC request payload cut by the payload size limit
+var jsonRPCMethodRegexp = regexp.MustCompile(`"method"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// Operation is the logical operation of a request, telling apart the requests of RPC-over-HTTP protocols
+// sharing a single URL, e.g. GraphQL, JSON-RPC or SOAP
+type Operation struct {
+	// Name suffixes the name of the request span, e.g. "[POST] /rpc user.get", if it is at most 64 characters long
+	// of letters, digits and "_.:/-" and, if operation names are allowed explicitly, allowed, see WithOperationNames
+	Name string
+	// Attributes are recorded on the request span
+	Attributes []trace.Attribute
+}
+
+// OperationExtractor resolves the operation of a handled request from the request and its payload,
+// captured up to the payload size limit and decompressed if the payload decompression is enabled.
+// The payload is empty if the handler did not read the request body. It returns false if the request
+// is not the one of an operation, e.g. it targets another endpoint or its operation cannot be parsed.
+type OperationExtractor func(r *http.Request, payload []byte) (Operation, bool)
+
+// WithOperationExtractor adds an extractor of the request operations, whose name suffixes the span name of the requests
+// and whose attributes are recorded on their spans. Extractors are tried in the order they were added,
+// the first one resolving the operation of the request wins. The name set with SetSpanName takes precedence anyway.
+func WithOperationExtractor(extractor OperationExtractor) Option {
+	return func(c *config) {
+		c.operationExtractors = append(c.operationExtractors, extractor)
+	}
+}
+
+// WithOperationNames allows only the operations of the provided names to suffix the span names,
+// e.g. "user.get" or "query:GetUser", keeping the span names of client-supplied operations bounded.
+// The operations of other names are still recorded by their attributes.
+func WithOperationNames(names ...string) Option {
+	return func(c *config) {
+		if c.operationNames == nil {
+			c.operationNames = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			c.operationNames[name] = true
+		}
+	}
+}
+
+// isOperationNameAllowed tells whether the operation name may suffix the span name
+func (c *config) isOperationNameAllowed(name string) bool {
+	if c.operationNames != nil {
+		return c.operationNames[name]
+	}
+	return operationNameRegexp.MatchString(name)
+}
+
+// JSONRPCOperationExtractor returns the extractor of the operations of the POST requests to the JSON-RPC endpoint
+// of the provided path, named after the method field of the request payload, e.g. "user.get", and recorded
+// as the rpc.system, rpc.method and rpc.jsonrpc.version attributes. The operations of batch requests are not resolved.
+func JSONRPCOperationExtractor(path string) OperationExtractor {
+	return func(r *http.Request, payload []byte) (Operation, bool) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			return Operation{}, false
+		}
+
+		if bytes.HasPrefix(bytes.TrimSpace(payload), []byte("[")) {
+			// a batch request of many operations
+			return Operation{}, false
+		}
+
+		var req struct {
+			Version string `json:"jsonrpc"`
+			Method  string `json:"method"`
+		}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			// the payload may be cut by the payload size limit
+			m := jsonRPCMethodRegexp.FindSubmatch(payload)
+			if m == nil {
+				return Operation{}, false
+			}
+			req.Method = string(m[1])
+		}
+		if req.Method == "" {
+			return Operation{}, false
+		}
+
+		attrs := []trace.Attribute{
+			trace.StringAttribute(spanRPCSystemAttributeKey, jsonRPCSystem),
+			trace.StringAttribute(spanRPCMethodAttributeKey, req.Method),
+		}
+		if req.Version != "" {
+			attrs = append(attrs, trace.StringAttribute(spanJSONRPCVersionAttributeKey, req.Version))
+		}
+		return Operation{Name: req.Method, Attributes: attrs}, true
+	}
+}
+
+// SOAPOperationExtractor returns the extractor of the operations of the POST requests to the SOAP endpoint
+// of the provided path, resolved from the SOAPAction header of SOAP 1.1, or the action parameter of the content type
+// of SOAP 1.2. The operations are named after the last segment of the action, e.g. "GetUser" for the action
+// "http://example.com/users/GetUser", the whole action is recorded as the soap.action attribute.
+func SOAPOperationExtractor(path string) OperationExtractor {
+	return func(r *http.Request, _ []byte) (Operation, bool) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			return Operation{}, false
+		}
+
+		action := strings.Trim(r.Header.Get(headerNameSOAPAction), `"`)
+		if action == "" {
+			if _, params, err := mime.ParseMediaType(r.Header.Get(headerNameContentType)); err == nil {
+				action = params[soapContentTypeActionParameterKey]
+			}
+		}
+		if action == "" {
+			return Operation{}, false
+		}
+
+		name := action
+		if i := strings.LastIndexAny(strings.TrimRight(name, "/#"), "/#:"); i >= 0 {
+			name = strings.TrimRight(name[i+1:], "/#")
+		}
+		return Operation{
+			Name:       name,
+			Attributes: []trace.Attribute{trace.StringAttribute(spanSOAPActionAttributeKey, action)},
+		}, true
+	}
+}
+
+// setSpanOperation suffixes the span name with the operation of the request resolved by the operation extractors
+// and records its attributes, requests of no operation are left as they are
+func setSpanOperation(span *trace.Span, r *http.Request, body *requestBodyDecorator, cfg *config) {
+	if len(cfg.operationExtractors) == 0 {
+		return
+	}
+
+	var payload []byte
+	if body != nil {
+		payload = body.Payload()
+		if cfg.payloadDecompression {
+			payload, _ = decompressPayload(r.Header.Get(headerNameContentEncoding), payload, cfg.payloadSizeLimit, body.PayloadTruncated())
+		}
+	}
+
+	for _, extract := range cfg.operationExtractors {
+		op, ok := extract(r, payload)
+		if !ok {
+			continue
+		}
+		if op.Name != "" && cfg.isOperationNameAllowed(op.Name) {
+			span.SetName(spanName(r, cfg) + " " + op.Name)
+		}
+		if len(op.Attributes) > 0 {
+			span.AddAttributes(op.Attributes...)
+		}
+		return
+	}
+}
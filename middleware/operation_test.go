@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_operation_extractors(t *testing.T) {
+	tests := []struct {
+		name               string
+		extractor          OperationExtractor
+		options            []Option
+		payload            string
+		headers            map[string]string
+		expectedSpanName   string
+		expectedAttributes map[string]string
+	}{
+		{
+			name:             "json-rpc method",
+			extractor:        JSONRPCOperationExtractor("/rpc"),
+			payload:          `{"jsonrpc":"2.0","method":"user.get","params":{"id":1},"id":1}`,
+			expectedSpanName: "[POST] /rpc user.get",
+			expectedAttributes: map[string]string{
+				"rpc.system":          "jsonrpc",
+				"rpc.method":          "user.get",
+				"rpc.jsonrpc.version": "2.0",
+			},
+		},
+		{
+			name:             "json-rpc batch",
+			extractor:        JSONRPCOperationExtractor("/rpc"),
+			payload:          `[{"jsonrpc":"2.0","method":"user.get","id":1},{"jsonrpc":"2.0","method":"user.list","id":2}]`,
+			expectedSpanName: "[POST] /rpc",
+		},
+		{
+			name:             "soap 1.1 action header",
+			extractor:        SOAPOperationExtractor("/rpc"),
+			payload:          `<soap:Envelope></soap:Envelope>`,
+			headers:          map[string]string{"SOAPAction": `"http://example.com/users/GetUser"`},
+			expectedSpanName: "[POST] /rpc GetUser",
+			expectedAttributes: map[string]string{
+				"soap.action": "http://example.com/users/GetUser",
+			},
+		},
+		{
+			name:             "soap 1.2 content type action",
+			extractor:        SOAPOperationExtractor("/rpc"),
+			payload:          `<soap:Envelope></soap:Envelope>`,
+			headers:          map[string]string{"Content-Type": `application/soap+xml; charset=utf-8; action="urn:DeleteUser"`},
+			expectedSpanName: "[POST] /rpc DeleteUser",
+			expectedAttributes: map[string]string{
+				"soap.action": "urn:DeleteUser",
+			},
+		},
+		{
+			name: "custom extractor",
+			extractor: func(r *http.Request, payload []byte) (Operation, bool) {
+				return Operation{
+					Name:       r.URL.Query().Get("op"),
+					Attributes: []trace.Attribute{trace.StringAttribute("op", r.URL.Query().Get("op"))},
+				}, true
+			},
+			expectedSpanName: "[POST] /rpc sync",
+			expectedAttributes: map[string]string{
+				"op": "sync",
+			},
+		},
+		{
+			name:             "unbounded operation name",
+			extractor:        JSONRPCOperationExtractor("/rpc"),
+			payload:          `{"jsonrpc":"2.0","method":"user get <script>","id":1}`,
+			expectedSpanName: "[POST] /rpc",
+			expectedAttributes: map[string]string{
+				"rpc.method": "user get <script>",
+			},
+		},
+		{
+			name:             "allowed operation name",
+			extractor:        JSONRPCOperationExtractor("/rpc"),
+			options:          []Option{WithOperationNames("user.get")},
+			payload:          `{"jsonrpc":"2.0","method":"user.get","id":1}`,
+			expectedSpanName: "[POST] /rpc user.get",
+		},
+		{
+			name:             "operation name not allowed",
+			extractor:        JSONRPCOperationExtractor("/rpc"),
+			options:          []Option{WithOperationNames("user.get")},
+			payload:          `{"jsonrpc":"2.0","method":"user.delete","id":1}`,
+			expectedSpanName: "[POST] /rpc",
+			expectedAttributes: map[string]string{
+				"rpc.method": "user.delete",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(append([]Option{WithOperationExtractor(tt.extractor)}, tt.options...)...))
+			r.Post("/rpc", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+			})
+
+			req, _ := http.NewRequest("POST", "/rpc?op=sync", strings.NewReader(tt.payload))
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+			for name, value := range tt.expectedAttributes {
+				if spanData.Attributes[name] != value {
+					t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", name, value)
+				}
+			}
+		})
+	}
+}
+
+func TestOpencensusTracing_operation_name_set_by_handler(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithOperationExtractor(JSONRPCOperationExtractor("/rpc"))))
+	r.Post("/rpc", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		SetSpanName(r.Context(), "rpc user.get")
+	})
+
+	req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"user.get","id":1}`))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "rpc user.get"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf("Expected the span name to be '%s', while it was '%s'", expectedSpanName, spanData.Name)
+	}
+}
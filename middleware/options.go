@@ -0,0 +1,366 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/exporters/console"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// NoPayloadSizeLimit disables the truncation of the captured payloads
+	NoPayloadSizeLimit = -1
+
+	// NotFoundRoutePattern stands for the route pattern of the requests matching no route,
+	// e.g. in the span name "[GET] route not found"
+	NotFoundRoutePattern = "route not found"
+	// MethodNotAllowedRoutePattern stands for the route pattern of the requests matching a route of other methods only,
+	// e.g. in the span name "[POST] method not allowed"
+	MethodNotAllowedRoutePattern = "method not allowed"
+
+	// URLParamNamespace is the prefix namespacing the URL param attributes apart from other span attributes
+	URLParamNamespace = "chi.param."
+
+	defaultPayloadSizeLimit = 256
+)
+
+// ParentPolicy defines how the span context of an incoming request relates to the request span
+type ParentPolicy int
+
+const (
+	// ParentAndLink makes the remote span the parent of the request span and also links to it
+	ParentAndLink ParentPolicy = iota
+	// ParentOnly makes the remote span the parent of the request span
+	ParentOnly
+	// LinkOnly starts the request span as a new root span linked to the remote span
+	LinkOnly
+)
+
+// Option configures the OpencensusTracing middleware
+type Option func(*config)
+
+type config struct {
+	propagator           propagation.Propagator
+	propagatorConfigured bool
+	payloadSizeLimit     int
+	noPayloadCapture     bool
+	payloadRedactors     []PayloadRedactor
+	spanNameFormatter    func(r *http.Request, routePattern string) string
+	filters              []func(r *http.Request) bool
+	samplerFunc          func(r *http.Request) trace.Sampler
+	parentPolicy         ParentPolicy
+	panicStackTrace      bool
+	statusMapper         func(statusCode int) trace.Status
+	errorStatusThreshold int
+	streamingDetection   bool
+	requestHeaders       []string
+	responseHeaders      []string
+	responseTrailers     []string
+	urlParamPrefix       string
+	urlParamMaskers      map[string]URLParamMasker
+	routeURLParamMaskers map[string]URLParamMasker
+	trustedProxies       []*net.IPNet
+
+	payloadDecompression     bool
+	requestBodyDecompression bool
+	binaryPayloadPolicy      BinaryPayloadPolicy
+	payloadsOnErrorOnly      bool
+	flushEvents              bool
+	forceExportOnError       bool
+
+	stats            bool
+	globalAttributes []trace.Attribute
+	spanStartHooks   []func(span *trace.Span, r *http.Request)
+	spanEndHooks     []func(span *trace.Span, r *http.Request, statusCode int, duration time.Duration)
+
+	otelTracer    oteltrace.Tracer
+	attributeKeys *attributeKeys
+	spanKind      int
+
+	traceIDResponseHeader string
+	sampledResponseHeader string
+	serverTimingHeader    bool
+
+	debugHeaderName  string
+	debugHeaderValue []byte
+
+	baggageHeader string
+
+	notFoundRoutePattern         string
+	methodNotAllowedRoutePattern string
+
+	operationExtractors []OperationExtractor
+	operationNames      map[string]bool
+
+	stripPropagationHeaders bool
+
+	slowRequestThreshold time.Duration
+	slowRequestStackDump bool
+
+	heartbeatInterval time.Duration
+
+	debugExporter *console.Exporter
+
+	maxAttributes           int
+	maxAttributeValueLength int
+	deniedAttributes        map[string]bool
+
+	routeOverrides []*routeOverride
+
+	now func() time.Time
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		propagator:        propagation.DefaultChain(),
+		payloadSizeLimit:  defaultPayloadSizeLimit,
+		spanNameFormatter: defaultSpanNameFormatter,
+		panicStackTrace:   true,
+		statusMapper:      DefaultStatusMapper,
+		attributeKeys:     openCensusAttributeKeys,
+		now:               time.Now,
+
+		notFoundRoutePattern:         NotFoundRoutePattern,
+		methodNotAllowedRoutePattern: MethodNotAllowedRoutePattern,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.resolveRouteOverrides()
+	return cfg
+}
+
+func (c *config) shouldTrace(r *http.Request) bool {
+	for _, filter := range c.filters {
+		if !filter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *config) resolveSampler(r *http.Request) trace.Sampler {
+	if c.isDebugRequest(r) {
+		return trace.AlwaysSample()
+	}
+	if c.samplerFunc == nil {
+		return nil
+	}
+	return c.samplerFunc(r)
+}
+
+func (c *config) runSpanStartHooks(span *trace.Span, r *http.Request) {
+	for _, hook := range c.spanStartHooks {
+		hook(span, r)
+	}
+}
+
+func (c *config) runSpanEndHooks(span *trace.Span, r *http.Request, statusCode int, duration time.Duration) {
+	for _, hook := range c.spanEndHooks {
+		hook(span, r, statusCode, duration)
+	}
+}
+
+func (c *config) spanStatus(statusCode int) trace.Status {
+	if statusCode < c.errorStatusThreshold {
+		return trace.Status{
+			Code:    trace.StatusCodeOK,
+			Message: "OK",
+		}
+	}
+	return c.statusMapper(statusCode)
+}
+
+// WithPropagators sets the ordered chain of formats used to extract the parent span context
+// from incoming requests. The first format recognized in the request headers wins.
+// By default, the binary header, W3C traceparent and B3 formats are tried in that order.
+// The span context of the outbound requests made while handling a request is injected in all the formats
+// of the chain, unless other propagators are provided, see AddTracingSpanToRequest and Transport.
+func WithPropagators(propagators ...propagation.Propagator) Option {
+	return func(c *config) {
+		c.propagator = propagatorChain(propagators)
+		c.propagatorConfigured = true
+	}
+}
+
+// WithPayloadSizeLimit sets the maximal size in bytes of the request and response payloads
+// recorded as span attributes. Payloads exceeding the limit are truncated.
+// The default limit is 256 bytes, NoPayloadSizeLimit disables the truncation.
+// The raw payload of multipart/form-data requests is never captured, the number of their parts,
+// their field names and their size are recorded instead.
+func WithPayloadSizeLimit(n int) Option {
+	return func(c *config) {
+		c.payloadSizeLimit = n
+	}
+}
+
+// WithPayloadRedactor adds a redactor applied to the request and response payloads
+// before they are recorded as span attributes. Redactors are applied in the order they were added.
+func WithPayloadRedactor(redactor func(payload []byte) []byte) Option {
+	return func(c *config) {
+		c.payloadRedactors = append(c.payloadRedactors, redactor)
+	}
+}
+
+// WithSpanNameFormatter sets the function resolving span names from the request and its chi route pattern.
+// By default, spans are named after the request method and the route pattern, e.g. "[GET] /users/{id}".
+func WithSpanNameFormatter(formatter func(r *http.Request, routePattern string) string) Option {
+	return func(c *config) {
+		c.spanNameFormatter = formatter
+	}
+}
+
+// WithFilter adds a predicate deciding whether the request should be traced.
+// Requests for which any of the filters returns false bypass the span creation entirely.
+func WithFilter(filter func(r *http.Request) bool) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, filter)
+	}
+}
+
+// WithSampler sets the sampler deciding whether the spans of this middleware are sampled,
+// overriding the global opencensus sampler for this router or route group only
+func WithSampler(sampler trace.Sampler) Option {
+	return WithSamplerFunc(func(*http.Request) trace.Sampler {
+		return sampler
+	})
+}
+
+// WithSamplerFunc sets the function resolving the sampler for every request before its span is started,
+// allowing e.g. to always sample debug routes while sampling only a fraction of high-volume ones.
+// If the function returns nil, the global opencensus sampler is used.
+func WithSamplerFunc(samplerFunc func(r *http.Request) trace.Sampler) Option {
+	return func(c *config) {
+		c.samplerFunc = samplerFunc
+	}
+}
+
+// WithPublicEndpoint marks the router as internet-facing. The span context of incoming requests
+// is not trusted to become the parent of the request spans; a new root span is started instead
+// and the incoming span context is only attached to it as a link.
+func WithPublicEndpoint() Option {
+	return WithParentPolicy(LinkOnly)
+}
+
+// WithParentPolicy sets how the span context of incoming requests relates to the request spans.
+// The default policy is ParentAndLink.
+func WithParentPolicy(policy ParentPolicy) Option {
+	return func(c *config) {
+		c.parentPolicy = policy
+	}
+}
+
+// WithPanicStackTrace enables or disables capturing the stack trace of a handler panic.
+// A panic always sets the span status to Internal and adds a panic annotation to the span,
+// with the stack trace attached to it unless disabled. The panic is then propagated further.
+func WithPanicStackTrace(enabled bool) Option {
+	return func(c *config) {
+		c.panicStackTrace = enabled
+	}
+}
+
+// WithStatusMapper sets the function mapping the HTTP status code of the response to the span status.
+// DefaultStatusMapper is used by default.
+func WithStatusMapper(mapper func(statusCode int) trace.Status) Option {
+	return func(c *config) {
+		c.statusMapper = mapper
+	}
+}
+
+// WithErrorStatusThreshold sets the lowest HTTP status code resulting in a non-OK span status,
+// e.g. 500 not to flag client errors as failed spans. Status codes below the threshold are mapped to OK,
+// the remaining ones are mapped by the status mapper. The http.status_code attribute is recorded regardless.
+func WithErrorStatusThreshold(statusCode int) Option {
+	return func(c *config) {
+		c.errorStatusThreshold = statusCode
+	}
+}
+
+// WithStreamingDetection enables the detection of streaming responses, i.e. server-sent events
+// and responses flushed by the handler. The capture of a streaming response payload stops at the payload size limit,
+// or at the default limit if there is none, so long-lived streams are not buffered in memory.
+func WithStreamingDetection() Option {
+	return func(c *config) {
+		c.streamingDetection = true
+	}
+}
+
+// WithURLParamPrefix sets the prefix of the span attributes recording the chi URL params,
+// e.g. URLParamNamespace for a param "id" to be recorded as "chi.param.id".
+// URL params are recorded under their raw names by default, which may collide with other span attributes.
+func WithURLParamPrefix(prefix string) Option {
+	return func(c *config) {
+		c.urlParamPrefix = prefix
+	}
+}
+
+// WithPayloadsOnErrorOnly records the request and response payloads of failed requests only,
+// keeping the spans of successful requests small and limiting the exposure of personal data.
+// A request fails if its handler panics, reports an error with SetSpanError, or its response status code
+// results in a non-OK span status, see WithErrorStatusThreshold and WithStatusMapper.
+func WithPayloadsOnErrorOnly() Option {
+	return func(c *config) {
+		c.payloadsOnErrorOnly = true
+	}
+}
+
+// WithFlushEvents adds a sent message event to the span every time the handler flushes the response,
+// numbered by the flush and sized by the number of bytes written so far,
+// so the chunk cadence of streaming responses, e.g. server-sent events, shows in the trace timeline.
+func WithFlushEvents() Option {
+	return func(c *config) {
+		c.flushEvents = true
+	}
+}
+
+// WithSpanStartHook adds a function called once the request span is started, before the request is handled,
+// e.g. to add custom attributes. Hooks are called for every traced request, including the ones not sampled,
+// whose spans record nothing. Hooks are called in the order they were added.
+func WithSpanStartHook(hook func(span *trace.Span, r *http.Request)) Option {
+	return func(c *config) {
+		c.spanStartHooks = append(c.spanStartHooks, hook)
+	}
+}
+
+// WithSpanEndHook adds a function called once the request is handled, right before the request span is ended,
+// with the response status code and the request duration, e.g. to emit metrics or log slow requests.
+// Hooks are called for every traced request, including the ones not sampled, whose spans record nothing.
+// Hooks are called in the order they were added.
+func WithSpanEndHook(hook func(span *trace.Span, r *http.Request, statusCode int, duration time.Duration)) Option {
+	return func(c *config) {
+		c.spanEndHooks = append(c.spanEndHooks, hook)
+	}
+}
+
+// WithGlobalAttributes adds the provided attributes to every request span of the router,
+// e.g. deployment metadata like trace.StringAttribute("service", "orders") or trace.StringAttribute("env", "prod")
+func WithGlobalAttributes(attrs ...trace.Attribute) Option {
+	return func(c *config) {
+		c.globalAttributes = append(c.globalAttributes, attrs...)
+	}
+}
+
+// WithClock sets the function telling the current time the request durations and timings recorded on the spans
+// are measured with, e.g. a fake clock making them deterministic in tests. The start and end times of the spans
+// are the ones of opencensus regardless.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// WithUnmatchedRoutePatterns sets the placeholders of the route pattern passed to the span name formatter
+// for the requests matching no chi route, answered with the 404 and 405 status codes respectively.
+// NotFoundRoutePattern and MethodNotAllowedRoutePattern are used by default, so the spans are named
+// e.g. "[GET] route not found" rather than after the URL path, keeping the span names of scanners and typos bounded.
+// The spans of requests matching no route have no route attribute nor URL params attributes.
+func WithUnmatchedRoutePatterns(notFound, methodNotAllowed string) Option {
+	return func(c *config) {
+		c.notFoundRoutePattern = notFound
+		c.methodNotAllowedRoutePattern = methodNotAllowed
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelpropagation "go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	otelMessageEventName           = "message"
+	otelMessageTypeAttributeKey    = "message.type"
+	otelMessageIDAttributeKey      = "message.id"
+	otelMessageUncompressedSizeKey = "message.uncompressed_size"
+	otelMessageCompressedSizeKey   = "message.compressed_size"
+	otelMessageTypeSent            = "SENT"
+	otelMessageTypeReceived        = "RECEIVED"
+)
+
+var (
+	otelMirrorOnce sync.Once
+	otelMirror     = &otelMirrorExporter{}
+)
+
+// WithOpenTelemetry emits every request span to OpenTelemetry as well, using the tracer, e.g. otel.Tracer("chi"),
+// so both systems receive the spans while migrating from opencensus. The OpenTelemetry span is a server span,
+// child of the OpenTelemetry span context extracted from the request headers with the global propagator,
+// see otel.SetTextMapPropagator, and is put in the request context along with the opencensus span.
+// Once the opencensus span is exported, its name, attributes, events and status are mirrored on the OpenTelemetry span,
+// so both spans are named and decorated the same. The OpenTelemetry span of a request not sampled by opencensus
+// is only named after the request.
+func WithOpenTelemetry(tracer oteltrace.Tracer) Option {
+	return func(c *config) {
+		c.otelTracer = tracer
+		otelMirrorOnce.Do(func() {
+			trace.RegisterExporter(otelMirror)
+		})
+	}
+}
+
+// startOtelSpan starts the OpenTelemetry span mirroring the opencensus span of the request
+func startOtelSpan(ctx context.Context, r *http.Request, span *trace.Span, state *requestState, start time.Time, cfg *config) (context.Context, oteltrace.Span) {
+	state.otelParent = oteltrace.SpanFromContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, otelpropagation.HeaderCarrier(r.Header))
+	ctx, otelSpan := cfg.otelTracer.Start(
+		ctx,
+		r.Method,
+		oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+		oteltrace.WithTimestamp(start),
+	)
+	otelMirror.spans.Store(span.SpanContext().SpanID, otelSpan)
+	return ctx, otelSpan
+}
+
+// endOtelSpan ends the OpenTelemetry span unless it has already been ended by the export of the opencensus span.
+// The span superseded by the span of a route, see OpencensusTracingNamed, is not ended, so it is never exported.
+func endOtelSpan(span *trace.Span, otelSpan oteltrace.Span, r *http.Request, state *requestState, cfg *config) {
+	if _, ok := otelMirror.spans.LoadAndDelete(span.SpanContext().SpanID); !ok || state.superseded {
+		return
+	}
+	otelSpan.SetName(spanName(r, cfg))
+	otelSpan.End()
+}
+
+// otelMirrorExporter mirrors the exported opencensus request spans on their OpenTelemetry counterparts
+type otelMirrorExporter struct {
+	spans sync.Map
+}
+
+func (e *otelMirrorExporter) ExportSpan(sd *trace.SpanData) {
+	v, ok := e.spans.LoadAndDelete(sd.SpanID)
+	if !ok {
+		return
+	}
+	otelSpan := v.(oteltrace.Span)
+
+	otelSpan.SetName(sd.Name)
+	otelSpan.SetAttributes(otelAttributes(sd.Attributes)...)
+	for _, a := range sd.Annotations {
+		otelSpan.AddEvent(
+			a.Message,
+			oteltrace.WithTimestamp(a.Time),
+			oteltrace.WithAttributes(otelAttributes(a.Attributes)...),
+		)
+	}
+	for _, m := range sd.MessageEvents {
+		otelSpan.AddEvent(
+			otelMessageEventName,
+			oteltrace.WithTimestamp(m.Time),
+			oteltrace.WithAttributes(otelMessageEventAttributes(m)...),
+		)
+	}
+	if sd.Status.Code != trace.StatusCodeOK {
+		otelSpan.SetStatus(codes.Error, sd.Status.Message)
+	}
+	otelSpan.End(oteltrace.WithTimestamp(sd.EndTime))
+}
+
+func otelMessageEventAttributes(m trace.MessageEvent) []attribute.KeyValue {
+	messageType := otelMessageTypeReceived
+	if m.EventType == trace.MessageEventTypeSent {
+		messageType = otelMessageTypeSent
+	}
+	return []attribute.KeyValue{
+		attribute.String(otelMessageTypeAttributeKey, messageType),
+		attribute.Int64(otelMessageIDAttributeKey, m.MessageID),
+		attribute.Int64(otelMessageUncompressedSizeKey, m.UncompressedByteSize),
+		attribute.Int64(otelMessageCompressedSizeKey, m.CompressedByteSize),
+	}
+}
+
+func otelAttributes(attributes map[string]interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		switch v := value.(type) {
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		}
+	}
+	return attrs
+}
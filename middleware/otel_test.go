@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestOpencensusTracing_open_telemetry(t *testing.T) {
+	_ = registerTestExporter()
+
+	tests := []struct {
+		name               string
+		sampler            trace.Sampler
+		statusCode         int
+		expectedAttributes []attribute.KeyValue
+		expectedStatusCode codes.Code
+	}{
+		{
+			name:       "sampled",
+			sampler:    trace.AlwaysSample(),
+			statusCode: http.StatusInternalServerError,
+			expectedAttributes: []attribute.KeyValue{
+				attribute.String("http.route", "/otel/{id}"),
+				attribute.Int64("http.status_code", http.StatusInternalServerError),
+			},
+			expectedStatusCode: codes.Error,
+		},
+		{
+			name:               "not sampled",
+			sampler:            trace.NeverSample(),
+			statusCode:         http.StatusInternalServerError,
+			expectedStatusCode: codes.Unset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			var handlerSpanContext oteltrace.SpanContext
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(WithOpenTelemetry(provider.Tracer("test")), WithSampler(tt.sampler)))
+			r.Get("/otel/{id}", func(w http.ResponseWriter, r *http.Request) {
+				handlerSpanContext = oteltrace.SpanContextFromContext(r.Context())
+				w.WriteHeader(tt.statusCode)
+			})
+
+			req, _ := http.NewRequest("GET", "/otel/1", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			expectedNumberOfSpans := 1
+			if len(spans) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(spans),
+				)
+			}
+
+			span := spans[0]
+
+			expectedName := "[GET] /otel/{id}"
+			if span.Name() != expectedName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", expectedName, span.Name())
+			}
+			if span.SpanKind() != oteltrace.SpanKindServer {
+				t.Fatalf("Expected the span to be a server span, while it was of kind '%s'", span.SpanKind())
+			}
+			if span.SpanContext().SpanID() != handlerSpanContext.SpanID() {
+				t.Fatalf("Expected the span to be passed to the handler in the request context")
+			}
+			if span.Status().Code != tt.expectedStatusCode {
+				t.Fatalf("Expected the span status code to be '%s', while it was '%s'", tt.expectedStatusCode, span.Status().Code)
+			}
+
+			attributes := map[attribute.Key]attribute.Value{}
+			for _, attr := range span.Attributes() {
+				attributes[attr.Key] = attr.Value
+			}
+			for _, attr := range tt.expectedAttributes {
+				if attributes[attr.Key] != attr.Value {
+					t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", attr.Key, attr.Value.Emit())
+				}
+			}
+		})
+	}
+}
+
+func TestOpencensusTracingNamed_open_telemetry(t *testing.T) {
+	_ = registerTestExporter()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("test")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithOpenTelemetry(tracer)))
+	r.With(OpencensusTracingNamed("checkout", WithOpenTelemetry(tracer))).Get("/c", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/c", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	expectedNumberOfSpans := 1
+	if len(spans) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(spans),
+		)
+	}
+
+	expectedName := "checkout"
+	if spans[0].Name() != expectedName {
+		t.Fatalf("Expected the span name to be '%s', while it was '%s'", expectedName, spans[0].Name())
+	}
+	if spans[0].Parent().IsValid() {
+		t.Fatal("Expected the span not to be a child of the superseded span")
+	}
+}
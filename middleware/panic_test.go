@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_panic(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(recoverer)
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatal("Expected the panic to be propagated to the recoverer")
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "[GET] /test"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			spanData.Name,
+		)
+	}
+
+	if spanData.Status.Code != trace.StatusCodeInternal {
+		t.Fatalf("Expected the span status to be '%d'", trace.StatusCodeInternal)
+	}
+
+	expectedNumberOfAnnotations := 1
+	if len(spanData.Annotations) != expectedNumberOfAnnotations {
+		t.Fatalf("Expected the span to have %d annotation(s), while it had %d", expectedNumberOfAnnotations, len(spanData.Annotations))
+	}
+
+	annotation := spanData.Annotations[0]
+
+	if annotation.Attributes["panic"] != "something went wrong" {
+		t.Fatal("Expected the annotation to carry the panic value")
+	}
+
+	stackTrace, _ := annotation.Attributes["stack_trace"].(string)
+	if !strings.Contains(stackTrace, "TestOpencensusTracing_panic") {
+		t.Fatal("Expected the annotation to carry the stack trace of the panic")
+	}
+}
+
+func TestOpencensusTracing_panic_without_stack_trace(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(recoverer)
+	r.Use(OpencensusTracing(WithPanicStackTrace(false)))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	if _, ok := spanData.Annotations[0].Attributes["stack_trace"]; ok {
+		t.Fatal("Expected the annotation not to carry the stack trace")
+	}
+}
+
+// recoverer answers a handler panic with the 500 status code, like the chi Recoverer middleware,
+// whose stack printing panics itself on recent Go versions with chi v5.0.3
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
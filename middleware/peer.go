@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanPeerIPAttributeKey     = "peer.ip"
+	spanPeerPortAttributeKey   = "peer.port"
+	spanFlavorAttributeKey     = "http.flavor"
+	spanSchemeAttributeKey     = "http.scheme"
+	headerNameForwarded        = "Forwarded"
+	headerNameXForwardedFor    = "X-Forwarded-For"
+	headerNameXForwardedProto  = "X-Forwarded-Proto"
+	invalidTrustedProxyMessage = "invalid trusted proxy: "
+)
+
+// WithTrustedProxies enables resolving the client address and scheme from the Forwarded header,
+// or the X-Forwarded-For and X-Forwarded-Proto headers, of requests coming from the provided proxies.
+// Proxies are given as IP addresses or CIDR ranges, e.g. "10.0.0.0/8"; an invalid one makes the option panic.
+// The client is the last address of the forwarding chain which is not a trusted proxy itself.
+// Forwarding headers of requests coming from other addresses are ignored, as they can be forged.
+func WithTrustedProxies(proxies ...string) Option {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		nets = append(nets, parseTrustedProxy(proxy))
+	}
+
+	return func(c *config) {
+		c.trustedProxies = append(c.trustedProxies, nets...)
+	}
+}
+
+func parseTrustedProxy(proxy string) *net.IPNet {
+	if strings.Contains(proxy, "/") {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			panic(invalidTrustedProxyMessage + proxy)
+		}
+		return ipNet
+	}
+
+	ip := net.ParseIP(proxy)
+	if ip == nil {
+		panic(invalidTrustedProxyMessage + proxy)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+func (c *config) isTrustedProxy(ip net.IP) bool {
+	for _, ipNet := range c.trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// setSpanPeerAttributes records where the request came from and over which protocol
+func setSpanPeerAttributes(span *trace.Span, r *http.Request, cfg *config) {
+	attrs := []trace.Attribute{
+		trace.StringAttribute(cfg.attributeKeys.flavor, httpFlavor(r)),
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	host, port, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host, port = r.RemoteAddr, ""
+	}
+	remoteIP := net.ParseIP(host)
+
+	if remoteIP != nil && cfg.isTrustedProxy(remoteIP) {
+		if clientIP := forwardedClientIP(r, cfg); clientIP != nil {
+			host, port = clientIP.String(), ""
+		}
+		if proto := forwardedProto(r); proto != "" {
+			scheme = proto
+		}
+	}
+
+	attrs = append(attrs, trace.StringAttribute(cfg.attributeKeys.scheme, scheme))
+	if host != "" {
+		attrs = append(attrs, trace.StringAttribute(cfg.attributeKeys.peerIP, host))
+	}
+	if p, err := strconv.ParseInt(port, 10, 64); err == nil {
+		attrs = append(attrs, trace.Int64Attribute(cfg.attributeKeys.peerPort, p))
+	}
+	span.AddAttributes(attrs...)
+}
+
+func httpFlavor(r *http.Request) string {
+	switch {
+	case r.ProtoMajor == 1 && r.ProtoMinor == 0:
+		return "1.0"
+	case r.ProtoMajor == 1:
+		return "1.1"
+	case r.ProtoMajor == 2:
+		return "2.0"
+	case r.ProtoMajor == 3:
+		return "3.0"
+	default:
+		return r.Proto
+	}
+}
+
+// forwardedClientIP walks the forwarding chain from the closest hop,
+// returning the first address which is not a trusted proxy, or nil if the chain holds no valid address
+func forwardedClientIP(r *http.Request, cfg *config) net.IP {
+	hops := forwardedFor(r)
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return nil
+		}
+		if i == 0 || !cfg.isTrustedProxy(ip) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// forwardedFor returns the addresses of the forwarding chain, the Forwarded header taking precedence
+func forwardedFor(r *http.Request) []string {
+	if values := r.Header.Values(headerNameForwarded); len(values) > 0 {
+		var hops []string
+		for _, element := range forwardedElements(values) {
+			if v, ok := element["for"]; ok {
+				hops = append(hops, stripPort(v))
+			}
+		}
+		return hops
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values(headerNameXForwardedFor) {
+		for _, hop := range strings.Split(v, ",") {
+			hops = append(hops, stripPort(strings.TrimSpace(hop)))
+		}
+	}
+	return hops
+}
+
+// forwardedProto returns the scheme requested by the client, as seen by the first proxy
+func forwardedProto(r *http.Request) string {
+	if values := r.Header.Values(headerNameForwarded); len(values) > 0 {
+		elements := forwardedElements(values)
+		if len(elements) > 0 {
+			return strings.ToLower(elements[0]["proto"])
+		}
+		return ""
+	}
+
+	proto := r.Header.Get(headerNameXForwardedProto)
+	if i := strings.IndexByte(proto, ','); i >= 0 {
+		proto = proto[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(proto))
+}
+
+// forwardedElements parses the Forwarded header values, see RFC 7239,
+// e.g. `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`
+func forwardedElements(values []string) []map[string]string {
+	var elements []map[string]string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			pairs := make(map[string]string)
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				pairs[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+			elements = append(elements, pairs)
+		}
+	}
+	return elements
+}
+
+// stripPort removes the optional port and IPv6 brackets from a forwarded address
+func stripPort(hop string) string {
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]")
+}
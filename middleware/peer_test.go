@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_peer_attributes(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []Option
+		remoteAddr     string
+		headers        map[string]string
+		expectedIP     string
+		expectedPort   interface{}
+		expectedScheme string
+	}{
+		{
+			name:           "remote address",
+			remoteAddr:     "192.0.2.1:1234",
+			headers:        map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expectedIP:     "192.0.2.1",
+			expectedPort:   int64(1234),
+			expectedScheme: "http",
+		},
+		{
+			name:       "x-forwarded-for from a trusted proxy",
+			opts:       []Option{WithTrustedProxies("10.0.0.0/8", "192.0.2.1")},
+			remoteAddr: "192.0.2.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.1, 203.0.113.7, 10.0.0.2",
+				"X-Forwarded-Proto": "https",
+			},
+			expectedIP:     "203.0.113.7",
+			expectedScheme: "https",
+		},
+		{
+			name:       "forwarded from a trusted proxy",
+			opts:       []Option{WithTrustedProxies("192.0.2.1")},
+			remoteAddr: "192.0.2.1:1234",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8::1]:4711";proto=https, for=192.0.2.1`,
+				"X-Forwarded-For": "203.0.113.7",
+			},
+			expectedIP:     "2001:db8::1",
+			expectedScheme: "https",
+		},
+		{
+			name:       "forwarded from an untrusted address",
+			opts:       []Option{WithTrustedProxies("10.0.0.0/8")},
+			remoteAddr: "192.0.2.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7",
+				"X-Forwarded-Proto": "https",
+			},
+			expectedIP:     "192.0.2.1",
+			expectedPort:   int64(1234),
+			expectedScheme: "http",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(tt.opts...))
+
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				t.Logf("Test call received")
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Attributes["peer.ip"] != tt.expectedIP {
+				t.Fatalf("Expected the span attribute of name 'peer.ip' to have value '%s'", tt.expectedIP)
+			}
+			if spanData.Attributes["peer.port"] != tt.expectedPort {
+				t.Fatalf("Expected the span attribute of name 'peer.port' to have value '%v'", tt.expectedPort)
+			}
+			if spanData.Attributes["http.scheme"] != tt.expectedScheme {
+				t.Fatalf("Expected the span attribute of name 'http.scheme' to have value '%s'", tt.expectedScheme)
+			}
+			if spanData.Attributes["http.flavor"] != "1.1" {
+				t.Fatal("Expected the span attribute of name 'http.flavor' to have value '1.1'")
+			}
+		})
+	}
+}
+
+func TestWithTrustedProxies_invalid_proxy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected an invalid trusted proxy to cause a panic")
+		}
+	}()
+	WithTrustedProxies("not an address")
+}
@@ -0,0 +1,382 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_b3_multi_header_parent(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-B3-TraceId", "463ac35c9f6413ad48485a3953bb6124")
+	req.Header.Set("X-B3-SpanId", "0020000000000001")
+	req.Header.Set("X-B3-Sampled", "1")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedTraceID := "463ac35c9f6413ad48485a3953bb6124"
+	if spanData.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, spanData.TraceID)
+	}
+
+	expectedParentSpanID := "0020000000000001"
+	if spanData.ParentSpanID.String() != expectedParentSpanID {
+		t.Fatalf("Expected parent span ID to be '%s', while the actual one was '%s'", expectedParentSpanID, spanData.ParentSpanID)
+	}
+}
+
+func TestOpencensusTracing_b3_single_header_parent(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedTraceID := "80f198ee56343ba864fe8b2a57d3eff7"
+	if spanData.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, spanData.TraceID)
+	}
+
+	expectedParentSpanID := "e457b5a2e4d86bd1"
+	if spanData.ParentSpanID.String() != expectedParentSpanID {
+		t.Fatalf("Expected parent span ID to be '%s', while the actual one was '%s'", expectedParentSpanID, spanData.ParentSpanID)
+	}
+}
+
+func TestAddTracingSpanToRequest_b3_headers(t *testing.T) {
+	_ = registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	ctx, span := trace.StartSpan(context.Background(), "testSpan")
+	AddTracingSpanToRequest(ctx, req)
+	span.End()
+
+	sc := span.SpanContext()
+
+	if req.Header.Get("X-B3-TraceId") != sc.TraceID.String() {
+		t.Fatalf("Expected X-B3-TraceId header to be '%s'", sc.TraceID)
+	}
+
+	if req.Header.Get("X-B3-SpanId") != sc.SpanID.String() {
+		t.Fatalf("Expected X-B3-SpanId header to be '%s'", sc.SpanID)
+	}
+
+	if req.Header.Get("X-B3-Sampled") != "1" {
+		t.Fatal("Expected X-B3-Sampled header to be '1'")
+	}
+
+	expectedSingleHeader := sc.TraceID.String() + "-" + sc.SpanID.String() + "-1"
+	if req.Header.Get("b3") != expectedSingleHeader {
+		t.Fatalf("Expected b3 header to be '%s'", expectedSingleHeader)
+	}
+}
+
+func TestOpencensusTracing_traceparent_parent(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedTraceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	if spanData.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, spanData.TraceID)
+	}
+
+	expectedParentSpanID := "00f067aa0ba902b7"
+	if spanData.ParentSpanID.String() != expectedParentSpanID {
+		t.Fatalf("Expected parent span ID to be '%s', while the actual one was '%s'", expectedParentSpanID, spanData.ParentSpanID)
+	}
+}
+
+func TestOpencensusTracing_with_propagators_ignores_other_formats(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPropagators(propagation.B3())))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	if spanData.ParentSpanID != (trace.SpanID{}) {
+		t.Fatalf("Expected the span to have no parent, while the parent span ID was '%s'", spanData.ParentSpanID)
+	}
+}
+
+func TestAddTracingSpanToRequest_selected_propagators(t *testing.T) {
+	_ = registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	ctx, span := trace.StartSpan(context.Background(), "testSpan")
+	AddTracingSpanToRequest(ctx, req, propagation.TraceContext())
+	span.End()
+
+	if req.Header.Get("traceparent") == "" {
+		t.Fatal("Expected traceparent header to be set")
+	}
+
+	if req.Header.Get("X-Opencensus-Span") != "" {
+		t.Fatal("Expected X-Opencensus-Span header not to be set")
+	}
+
+	if req.Header.Get("b3") != "" {
+		t.Fatal("Expected b3 header not to be set")
+	}
+}
+
+func TestAddTracingSpanToRequest_all_default_formats(t *testing.T) {
+	_ = registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	ctx, span := trace.StartSpan(context.Background(), "testSpan")
+	AddTracingSpanToRequest(ctx, req)
+	span.End()
+
+	for _, name := range []string{"X-Opencensus-Span", "traceparent", "X-B3-TraceId"} {
+		if req.Header.Get(name) == "" {
+			t.Fatalf("Expected %s header to be set", name)
+		}
+	}
+}
+
+func TestAddTracingSpanToRequest_middleware_propagators(t *testing.T) {
+	_ = registerTestExporter()
+
+	var outbound *http.Request
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPropagators(propagation.Datadog(), propagation.TraceContext())))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		// a span of the handler still injects the formats of the middleware
+		ctx, span := trace.StartSpan(r.Context(), "child")
+		defer span.End()
+		outbound, _ = http.NewRequest("GET", "http://upstream/", nil)
+		AddTracingSpanToRequest(ctx, outbound)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, name := range []string{"X-Datadog-Trace-Id", "traceparent"} {
+		if outbound.Header.Get(name) == "" {
+			t.Fatalf("Expected %s header to be set", name)
+		}
+	}
+	for _, name := range []string{"X-Opencensus-Span", "X-B3-TraceId"} {
+		if outbound.Header.Get(name) != "" {
+			t.Fatalf("Expected %s header not to be set", name)
+		}
+	}
+}
+
+func TestOpencensusTracing_public_endpoint_links_remote_parent(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithPublicEndpoint()))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		t.Logf("Test call received")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	if spanData.ParentSpanID != (trace.SpanID{}) {
+		t.Fatalf("Expected the span to have no parent, while the parent span ID was '%s'", spanData.ParentSpanID)
+	}
+
+	if spanData.TraceID.String() == "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatal("Expected the span to start a new trace")
+	}
+
+	expectedNumberOfLinks := 1
+	if len(spanData.Links) != expectedNumberOfLinks {
+		t.Fatalf("Expected the span to have %d link(s), while it had %d", expectedNumberOfLinks, len(spanData.Links))
+	}
+
+	expectedLinkedSpanID := "00f067aa0ba902b7"
+	if spanData.Links[0].SpanID.String() != expectedLinkedSpanID {
+		t.Fatalf("Expected the span to be linked to span '%s'", expectedLinkedSpanID)
+	}
+}
+
+func TestOpencensusTracing_parent_policy(t *testing.T) {
+	cases := []struct {
+		policy                ParentPolicy
+		expectedParentSpanID  string
+		expectedNumberOfLinks int
+	}{
+		{policy: ParentAndLink, expectedParentSpanID: "00f067aa0ba902b7", expectedNumberOfLinks: 1},
+		{policy: ParentOnly, expectedParentSpanID: "00f067aa0ba902b7", expectedNumberOfLinks: 0},
+		{policy: LinkOnly, expectedParentSpanID: "0000000000000000", expectedNumberOfLinks: 1},
+	}
+
+	for _, c := range cases {
+		exporter := registerTestExporter()
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		r := chi.NewRouter()
+		r.Use(OpencensusTracing(WithParentPolicy(c.policy)))
+
+		r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+			t.Logf("Test call received")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		expectedNumberOfSpans := 1
+		if len(exporter.collected) != expectedNumberOfSpans {
+			t.Fatalf(
+				"Expected to collect %d span(s), while there were %d span(s) collected",
+				expectedNumberOfSpans,
+				len(exporter.collected),
+			)
+		}
+
+		spanData := exporter.collected[0]
+
+		if spanData.ParentSpanID.String() != c.expectedParentSpanID {
+			t.Fatalf(
+				"Expected parent span ID to be '%s' for policy %d, while the actual one was '%s'",
+				c.expectedParentSpanID,
+				c.policy,
+				spanData.ParentSpanID,
+			)
+		}
+
+		if len(spanData.Links) != c.expectedNumberOfLinks {
+			t.Fatalf(
+				"Expected the span to have %d link(s) for policy %d, while it had %d",
+				c.expectedNumberOfLinks,
+				c.policy,
+				len(spanData.Links),
+			)
+		}
+	}
+}
+
+func TestRequestWithTracingSpan(t *testing.T) {
+	_ = registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	ctx, span := trace.StartSpan(context.Background(), "testSpan")
+	tracedReq := RequestWithTracingSpan(ctx, req, propagation.TraceContext())
+	span.End()
+
+	if len(req.Header) != 0 {
+		t.Fatal("Expected the original request not to be modified")
+	}
+	if tracedReq.Context() != req.Context() {
+		t.Fatal("Expected the cloned request to keep the context of the original request")
+	}
+
+	expectedTraceParent := propagation.TraceParent(span.SpanContext())
+	if tracedReq.Header.Get("traceparent") != expectedTraceParent {
+		t.Fatalf("Expected traceparent header to be '%s', while it was '%s'", expectedTraceParent, tracedReq.Header.Get("traceparent"))
+	}
+}
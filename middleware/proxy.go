@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+)
+
+// ProxyDirector wraps the director of an httputil.ReverseProxy, injecting the span context of the request span,
+// along with the baggage, to the outbound request once it is directed by the base director, see RequestWithTracingSpan.
+// The span context is injected using the provided propagators, or the formats the middleware is configured with
+// if none are provided, see AddTracingSpanToRequest, replacing the span context headers copied from the inbound request,
+// so the upstreams continue the trace of the gateway span rather than the one of the caller.
+func ProxyDirector(base func(*http.Request), propagators ...propagation.Propagator) func(*http.Request) {
+	return func(r *http.Request) {
+		if base != nil {
+			base(r)
+		}
+		injectTracingSpan(r.Context(), r, outboundPropagator(r.Context(), propagators))
+	}
+}
+
+// NewSingleHostReverseProxy returns an httputil.ReverseProxy routing the requests to the provided target,
+// like httputil.NewSingleHostReverseProxy, with its director wrapped by ProxyDirector
+func NewSingleHostReverseProxy(target *url.URL, propagators ...propagation.Propagator) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Director = ProxyDirector(proxy.Director, propagators...)
+	return proxy
+}
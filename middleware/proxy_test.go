@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+func TestNewSingleHostReverseProxy(t *testing.T) {
+	exporter := registerTestExporter()
+
+	var upstreamSpanContext trace.SpanContext
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamSpanContext, _ = propagation.TraceContext().Extract(r.Header)
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+	r.Handle("/api/*", NewSingleHostReverseProxy(target))
+
+	callerSpanContext := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+	req, _ := http.NewRequest("GET", "/api/users", nil)
+	propagation.TraceContext().Inject(callerSpanContext, req.Header)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	if upstreamSpanContext.TraceID != callerSpanContext.TraceID {
+		t.Fatalf("Expected the upstream to continue the trace '%s', while it was '%s'", callerSpanContext.TraceID, upstreamSpanContext.TraceID)
+	}
+	if upstreamSpanContext.SpanID != spanData.SpanID {
+		t.Fatalf("Expected the upstream parent span to be the gateway span '%s', while it was '%s'", spanData.SpanID, upstreamSpanContext.SpanID)
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+
+	hashedURLParamPrefix = "sha256:"
+	hashedURLParamLength = 16
+	maskedURLParamSuffix = "***"
+)
+
+var defaultRedactedJSONFields = []string{"password", "token", "secret", "ssn"}
+
+// PayloadRedactor transforms a captured payload before it is recorded as a span attribute
+type PayloadRedactor func(payload []byte) []byte
+
+// RegexpRedactor returns a redactor masking all the matches of the provided expression
+func RegexpRedactor(re *regexp.Regexp) PayloadRedactor {
+	return func(payload []byte) []byte {
+		return re.ReplaceAll(payload, []byte(redactedValue))
+	}
+}
+
+// JSONFieldRedactor returns a redactor masking the values of the JSON fields of the provided names,
+// regardless of their nesting level. Field names are matched case-insensitively.
+// A string value cut by the payload size limit is masked up to the end of the payload.
+// If no names are provided, password, token, secret and ssn fields are masked.
+func JSONFieldRedactor(fields ...string) PayloadRedactor {
+	if len(fields) == 0 {
+		fields = defaultRedactedJSONFields
+	}
+
+	quoted := make([]string, 0, len(fields))
+	for _, f := range fields {
+		quoted = append(quoted, regexp.QuoteMeta(f))
+	}
+
+	// matches "field": followed by a string, number, boolean or null value,
+	// or by a string left unterminated by the truncation of the payload, up to the end of the payload
+	re := regexp.MustCompile(
+		`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|"(?:[^"\\]|\\.)*\\?\z|[^\s,}\]]+)`,
+	)
+	replacement := []byte(`${1}"` + redactedValue + `"`)
+
+	return func(payload []byte) []byte {
+		return re.ReplaceAll(payload, replacement)
+	}
+}
+
+func redactPayload(payload []byte, redactors []PayloadRedactor) []byte {
+	for _, redact := range redactors {
+		payload = redact(payload)
+	}
+	return payload
+}
+
+// URLParamMasker transforms the value of a URL param before it is recorded as a span attribute,
+// e.g. to keep the personal data of routes like "/users/{email}" out of the traces
+type URLParamMasker func(value string) string
+
+// HashURLParam returns a masker replacing the value with the first 16 hex digits of its salted SHA-256 hash,
+// e.g. "sha256:9f86d081884c7d65", so the requests of the same value can still be told apart
+func HashURLParam(salt string) URLParamMasker {
+	return func(value string) string {
+		sum := sha256.Sum256([]byte(salt + value))
+		return hashedURLParamPrefix + hex.EncodeToString(sum[:])[:hashedURLParamLength]
+	}
+}
+
+// PartialMaskURLParam returns a masker keeping the first n characters of the value only, e.g. "jo***"
+func PartialMaskURLParam(n int) URLParamMasker {
+	return func(value string) string {
+		i := 0
+		for count := 0; i < len(value) && count < n; count++ {
+			_, size := utf8.DecodeRuneInString(value[i:])
+			i += size
+		}
+		return value[:i] + maskedURLParamSuffix
+	}
+}
+
+// WithURLParamMasker masks the values of the URL params of the provided names, on any route,
+// before they are recorded as span attributes, e.g. WithURLParamMasker(HashURLParam(salt), "email").
+// The values are masked in the http.path attribute as well.
+func WithURLParamMasker(masker URLParamMasker, names ...string) Option {
+	return func(c *config) {
+		if c.urlParamMaskers == nil {
+			c.urlParamMaskers = make(map[string]URLParamMasker, len(names))
+		}
+		for _, name := range names {
+			c.urlParamMaskers[name] = masker
+		}
+	}
+}
+
+// WithRouteURLParamMasker masks the values of all the URL params of the route of the provided pattern,
+// e.g. "/users/{email}", before they are recorded as span attributes, see WithURLParamMasker.
+// The masker of the param name takes precedence.
+func WithRouteURLParamMasker(routePattern string, masker URLParamMasker) Option {
+	return func(c *config) {
+		if c.routeURLParamMaskers == nil {
+			c.routeURLParamMaskers = make(map[string]URLParamMasker)
+		}
+		c.routeURLParamMaskers[routePattern] = masker
+	}
+}
+
+// maskPath replaces the value of a URL param in the path, preferably at the start of a path segment
+func maskPath(path, value, masked string) string {
+	i := strings.Index(path, "/"+value)
+	if i < 0 {
+		return strings.Replace(path, value, masked, 1)
+	}
+	return path[:i+1] + masked + path[i+1+len(value):]
+}
+
+// urlParamMasker returns the masker of the URL param of the route, or nil if the param is recorded as it is
+func (c *config) urlParamMasker(routePattern, name string) URLParamMasker {
+	if masker, ok := c.urlParamMaskers[name]; ok {
+		return masker
+	}
+	return c.routeURLParamMaskers[routePattern]
+}
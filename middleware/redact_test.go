@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_payload_attributes_redacted(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := []byte(`{"user":"john","password":"secret123"}`)
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(reqBody))
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithPayloadRedactor(JSONFieldRedactor()),
+		WithPayloadRedactor(RegexpRedactor(regexp.MustCompile(`\d{4}-\d{4}`))),
+	))
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte("card 1234-5678 accepted"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterName := "request_payload"
+	expectedParameterAttribute := `{"user":"john","password":"[REDACTED]"}`
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+
+	expectedParameterName = "response_payload"
+	expectedParameterAttribute = "card [REDACTED] accepted"
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+
+	if w.Body.String() != "card 1234-5678 accepted" {
+		t.Fatal("Expected the response sent to the client not to be redacted")
+	}
+}
+
+func TestOpencensusTracing_payload_attributes_redacted_when_truncated(t *testing.T) {
+	exporter := registerTestExporter()
+
+	reqBody := []byte(`{"user":"john","password":"` + strings.Repeat("correct horse battery staple ", 4) + `"}`)
+	req, _ := http.NewRequest("POST", "/test", bytes.NewReader(reqBody))
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithPayloadSizeLimit(100),
+		WithPayloadRedactor(JSONFieldRedactor()),
+	))
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	payload, _ := exporter.collected[0].Attributes["request_payload"].(string)
+	for _, secret := range []string{"correct", "horse", "battery"} {
+		if strings.Contains(payload, secret) {
+			t.Fatalf("Expected the secret cut at the payload size limit to be redacted, while the payload was '%s'", payload)
+		}
+	}
+	if payload != `{"user":"john","password":"[REDACTED]"`+payloadTruncatedMessage {
+		t.Fatalf("Expected the truncated payload to be redacted, while it was '%s'", payload)
+	}
+}
+
+func TestJSONFieldRedactor(t *testing.T) {
+	cases := []struct {
+		payload  string
+		expected string
+	}{
+		{
+			payload:  `{"Token": "abc\"def", "id": 1}`,
+			expected: `{"Token": "[REDACTED]", "id": 1}`,
+		},
+		{
+			payload:  `{"user":{"ssn":123456789,"name":"john"}}`,
+			expected: `{"user":{"ssn":"[REDACTED]","name":"john"}}`,
+		},
+		{
+			payload:  `[{"secret":null},{"secret":true}]`,
+			expected: `[{"secret":"[REDACTED]"},{"secret":"[REDACTED]"}]`,
+		},
+		{
+			payload:  `{"password_hint":"pet name"}`,
+			expected: `{"password_hint":"pet name"}`,
+		},
+		{
+			payload:  `{"id":1,"password":"abc def, ghi`,
+			expected: `{"id":1,"password":"[REDACTED]"`,
+		},
+		{
+			payload:  `{"token":"abc\`,
+			expected: `{"token":"[REDACTED]"`,
+		},
+	}
+
+	redact := JSONFieldRedactor()
+	for _, c := range cases {
+		actual := string(redact([]byte(c.payload)))
+		if actual != c.expected {
+			t.Fatalf("Expected payload '%s' to be redacted to '%s', while the actual result was '%s'", c.payload, c.expected, actual)
+		}
+	}
+}
+
+func TestOpencensusTracing_url_param_masking(t *testing.T) {
+	tests := []struct {
+		name               string
+		opts               []Option
+		expectedAttributes map[string]string
+	}{
+		{
+			name: "hashed param",
+			opts: []Option{WithURLParamMasker(HashURLParam(""), "email")},
+			expectedAttributes: map[string]string{
+				"email":     "sha256:b4c9a289323b21a0",
+				"id":        "1",
+				"http.path": "/users/sha256:b4c9a289323b21a0/orders/1",
+			},
+		},
+		{
+			name: "partially masked route params",
+			opts: []Option{WithRouteURLParamMasker("/users/{email}/orders/{id}", PartialMaskURLParam(2))},
+			expectedAttributes: map[string]string{
+				"email":     "us***",
+				"id":        "1***",
+				"http.path": "/users/us***/orders/1***",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(tt.opts...))
+			r.Get("/users/{email}/orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+			req, _ := http.NewRequest("GET", "/users/user@example.com/orders/1", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spanData := exporter.collected[0]
+			for name, value := range tt.expectedAttributes {
+				if spanData.Attributes[name] != value {
+					t.Fatalf("Expected the span attribute of name '%s' to have value '%s', while it was '%v'", name, value, spanData.Attributes[name])
+				}
+			}
+		})
+	}
+}
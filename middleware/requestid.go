@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.opencensus.io/trace"
+)
+
+const spanRequestIDAttributeKey = "http.request_id"
+
+// setSpanRequestIDAttribute records the request ID set by the chi RequestID middleware,
+// which has to precede the tracing middleware in the chain for the ID to be known
+func setSpanRequestIDAttribute(span *trace.Span, r *http.Request) {
+	if requestID := chimiddleware.GetReqID(r.Context()); requestID != "" {
+		span.AddAttributes(trace.StringAttribute(spanRequestIDAttributeKey, requestID))
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestOpencensusTracing_request_id_correlation(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(chimiddleware.RequestID)
+	r.Use(OpencensusTracing())
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", TraceIDFromContext(r.Context()))
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-Id", "request-id")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedAttributeName := "http.request_id"
+	expectedAttributeValue := "request-id"
+	if spanData.Attributes[expectedAttributeName] != expectedAttributeValue {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedAttributeName, expectedAttributeValue)
+	}
+
+	expectedTraceID := spanData.TraceID.String()
+	if w.Header().Get("X-Request-Id") != expectedTraceID {
+		t.Fatalf("Expected the X-Request-Id response header to be '%s', while it was '%s'", expectedTraceID, w.Header().Get("X-Request-Id"))
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.20
+// +build go1.20
+
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_response_controller(t *testing.T) {
+	_ = registerTestExporter()
+
+	errs := make(chan error, 4)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Post("/test", func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		errs <- rc.SetReadDeadline(time.Now().Add(time.Minute))
+		errs <- rc.SetWriteDeadline(time.Now().Add(time.Minute))
+		errs <- rc.EnableFullDuplex()
+		_, _ = w.Write([]byte("RESPONSE"))
+		errs <- rc.Flush()
+		_, _ = io.ReadAll(r.Body)
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/test", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed, while it failed with: %s", err)
+	}
+	_ = resp.Body.Close()
+
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Expected the response controller call to succeed, while it failed with: %s", err)
+		}
+	}
+}
+
+func TestOpencensusTracing_response_controller_not_supported(t *testing.T) {
+	_ = registerTestExporter()
+
+	var err error
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		err = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(time.Minute))
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if !errors.Is(err, http.ErrNotSupported) {
+		t.Fatalf("Expected the response controller call to fail with '%s', while the error was: %v", http.ErrNotSupported, err)
+	}
+}
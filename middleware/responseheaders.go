@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+const headerNameServerTiming = "Server-Timing"
+
+// WithTraceIDResponseHeader sets the trace ID of the request span in the response header of the provided name,
+// e.g. "X-Trace-Id", so the trace of a request reported by a user can be looked up straight in the tracing UI.
+// The header is set before the request is handled, for sampled and not sampled requests alike.
+func WithTraceIDResponseHeader(name string) Option {
+	return func(c *config) {
+		c.traceIDResponseHeader = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithSampledResponseHeader sets whether the request span is sampled, "true" or "false",
+// in the response header of the provided name, e.g. "X-Trace-Sampled", telling whether the trace can be looked up
+func WithSampledResponseHeader(name string) Option {
+	return func(c *config) {
+		c.sampledResponseHeader = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithServerTimingHeader adds the span context of the request span to the Server-Timing response header,
+// e.g. Server-Timing: traceparent;desc="00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+// so browser-side RUM agents can link the frontend spans to the request span.
+// Browsers expose the header to cross-origin pages only if allowed by the Timing-Allow-Origin header.
+func WithServerTimingHeader() Option {
+	return func(c *config) {
+		c.serverTimingHeader = true
+	}
+}
+
+func setSpanResponseHeaders(w http.ResponseWriter, sc trace.SpanContext, cfg *config) {
+	if cfg.traceIDResponseHeader != "" {
+		w.Header().Set(cfg.traceIDResponseHeader, sc.TraceID.String())
+	}
+	if cfg.sampledResponseHeader != "" {
+		w.Header().Set(cfg.sampledResponseHeader, strconv.FormatBool(sc.IsSampled()))
+	}
+	if cfg.serverTimingHeader {
+		w.Header().Add(headerNameServerTiming, `traceparent;desc="`+propagation.TraceParent(sc)+`"`)
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_trace_id_response_header(t *testing.T) {
+	tests := []struct {
+		name            string
+		sampler         trace.Sampler
+		expectedSampled string
+	}{
+		{
+			name:            "sampled",
+			sampler:         trace.AlwaysSample(),
+			expectedSampled: "true",
+		},
+		{
+			name:            "not sampled",
+			sampler:         trace.NeverSample(),
+			expectedSampled: "false",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var traceID string
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(
+				WithSampler(tt.sampler),
+				WithTraceIDResponseHeader("x-trace-id"),
+				WithSampledResponseHeader("X-Trace-Sampled"),
+			))
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				traceID = trace.FromContext(r.Context()).SpanContext().TraceID.String()
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Header().Get("X-Trace-Id") != traceID {
+				t.Fatalf("Expected the trace ID response header to be '%s', while it was '%s'", traceID, w.Header().Get("X-Trace-Id"))
+			}
+			if w.Header().Get("X-Trace-Sampled") != tt.expectedSampled {
+				t.Fatalf("Expected the sampled response header to be '%s', while it was '%s'", tt.expectedSampled, w.Header().Get("X-Trace-Sampled"))
+			}
+		})
+	}
+}
+
+func TestOpencensusTracing_server_timing_header(t *testing.T) {
+	var sc trace.SpanContext
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSampler(trace.AlwaysSample()), WithServerTimingHeader()))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		sc = trace.FromContext(r.Context()).SpanContext()
+		w.Header().Add("Server-Timing", "db;dur=53")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedValues := []string{
+		fmt.Sprintf(`traceparent;desc="00-%s-%s-01"`, sc.TraceID, sc.SpanID),
+		"db;dur=53",
+	}
+	values := w.Header().Values("Server-Timing")
+	if len(values) != len(expectedValues) {
+		t.Fatalf("Expected %d Server-Timing header value(s), while there were %d", len(expectedValues), len(values))
+	}
+	for i, value := range expectedValues {
+		if values[i] != value {
+			t.Fatalf("Expected the Server-Timing header value to be '%s', while it was '%s'", value, values[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+const spanRetryCountAttributeKey = "http.retry_count"
+
+type retriesKey struct{}
+
+// retries counts the attempts of an outgoing request made within the context of StartRetries
+type retries struct {
+	attempts int64
+}
+
+// StartRetries starts a span of an outgoing request sent many times, e.g. by a retrying client,
+// as a child of the span of the context. The client spans of the Transport started within the returned context
+// are the spans of the attempts of the request, children of the returned span, numbered from 0 by the
+// http.retry_count attribute. The span is ended by calling the returned func once the request is done retrying.
+func StartRetries(ctx context.Context, name string) (context.Context, func()) {
+	ctx, span := trace.StartSpan(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return context.WithValue(ctx, retriesKey{}, &retries{}), span.End
+}
+
+// nextRetryCount returns the number of the attempt of the request of the context, false if it is not retried
+func nextRetryCount(ctx context.Context) (int64, bool) {
+	r, ok := ctx.Value(retriesKey{}).(*retries)
+	if !ok {
+		return 0, false
+	}
+	return atomic.AddInt64(&r.attempts, 1) - 1, true
+}
+
+// isSpanInjected tells whether the span context of the span has already been injected to the request,
+// along with a message event ID, e.g. by a previous attempt of a reused request
+func isSpanInjected(span *trace.Span, r *http.Request, p propagation.Propagator) bool {
+	if r.Header.Get(headerNameOpencensusSpanEventIDKey) == "" {
+		return false
+	}
+	sc, ok := p.Extract(r.Header)
+	return ok && sc.TraceID == span.SpanContext().TraceID && sc.SpanID == span.SpanContext().SpanID
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestStartRetries(t *testing.T) {
+	exporter := registerTestExporter()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := WrapClient(server.Client())
+
+	ctx, end := StartRetries(context.Background(), "get test")
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/test", nil)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected the request to succeed, while it failed with: %s", err)
+		}
+		_ = resp.Body.Close()
+	}
+	end()
+
+	expectedNumberOfSpans := 3
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	retriesSpanData := exporter.collected[2]
+	for i, attemptSpanData := range exporter.collected[:2] {
+		if attemptSpanData.ParentSpanID != retriesSpanData.SpanID {
+			t.Fatalf("Expected the attempt span to be a child of the retries span")
+		}
+
+		expectedAttributeName := "http.retry_count"
+		expectedAttributeValue := int64(i)
+		if attemptSpanData.Attributes[expectedAttributeName] != expectedAttributeValue {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%d'", expectedAttributeName, expectedAttributeValue)
+		}
+	}
+}
+
+func TestAddTracingSpanToRequest_reused_request(t *testing.T) {
+	exporter := registerTestExporter()
+
+	ctx, span := trace.StartSpan(context.Background(), "test")
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	AddTracingSpanToRequest(ctx, req)
+	eID := req.Header.Get("X-Opencensus-Event-Id")
+	AddTracingSpanToRequest(ctx, req)
+	span.End()
+
+	if req.Header.Get("X-Opencensus-Event-Id") != eID {
+		t.Fatalf("Expected the event ID '%s' not to be replaced, while it was '%s'", eID, req.Header.Get("X-Opencensus-Event-Id"))
+	}
+
+	expectedNumberOfMessageEvents := 1
+	if len(exporter.collected[0].MessageEvents) != expectedNumberOfMessageEvents {
+		t.Fatalf(
+			"Expected the span to have %d message event(s), while it had %d",
+			expectedNumberOfMessageEvents,
+			len(exporter.collected[0].MessageEvents),
+		)
+	}
+}
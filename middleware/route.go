@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// resolveRoutePattern resolves the chi route pattern the request is going to be routed to.
+// It allows route aware decisions to be taken before the routing itself takes place,
+// e.g. in a middleware registered on the router. An empty string is returned for unmatched requests.
+func resolveRoutePattern(r *http.Request) string {
+	rCtx := chi.RouteContext(r.Context())
+	if rCtx == nil || rCtx.Routes == nil {
+		return ""
+	}
+
+	// the routes are the ones of the root router, even in a middleware of a mounted subrouter,
+	// so the whole path is matched
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	tCtx := chi.NewRouteContext()
+	if !rCtx.Routes.Match(tCtx, r.Method, path) {
+		return ""
+	}
+	return fullRoutePattern(tCtx)
+}
+
+// fullRoutePattern reconstructs the pattern of the route matched across the mounted subrouters and route groups,
+// e.g. "/api/users/{id}" for the route "/users/{id}" of a subrouter mounted at "/api", whose own pattern is "/api/*".
+// Unlike chi.Context.RoutePattern, the patterns the mount points are registered with, i.e. "/api", "/api/"
+// and "/api/*", all result in a single slash between the mount point and the subrouter route.
+func fullRoutePattern(rCtx *chi.Context) string {
+	patterns := rCtx.RoutePatterns
+	switch len(patterns) {
+	case 0:
+		return ""
+	case 1:
+		return patterns[0]
+	}
+
+	var b strings.Builder
+	for _, pattern := range patterns[:len(patterns)-1] {
+		pattern = strings.TrimSuffix(pattern, "/*")
+		pattern = strings.TrimSuffix(pattern, "/")
+		b.WriteString(pattern)
+	}
+	b.WriteString(patterns[len(patterns)-1])
+	return b.String()
+}
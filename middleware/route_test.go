@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestResolveRoutePattern_mounted_subrouter(t *testing.T) {
+	var resolved string
+
+	sub := chi.NewRouter()
+	sub.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resolved = resolveRoutePattern(r)
+			next.ServeHTTP(w, r)
+		})
+	})
+	sub.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	r := chi.NewRouter()
+	r.Mount("/api", sub)
+
+	req, _ := http.NewRequest("GET", "/api/users/42", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedRoutePattern := "/api/users/{id}"
+	if resolved != expectedRoutePattern {
+		t.Fatalf("Expected the route pattern to be '%s', while the actual one was '%s'", expectedRoutePattern, resolved)
+	}
+}
+
+func TestOpencensusTracing_span_name_without_route_pattern(t *testing.T) {
+	tests := []struct {
+		name             string
+		handler          func() http.Handler
+		method           string
+		expectedSpanName string
+	}{
+		{
+			name: "default not found handler",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+				return r
+			},
+			method:           "GET",
+			expectedSpanName: "[GET] route not found",
+		},
+		{
+			name: "custom not found handler",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, "not found", http.StatusNotFound)
+				})
+				r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+				return r
+			},
+			method:           "GET",
+			expectedSpanName: "[GET] route not found",
+		},
+		{
+			name: "not found handler serving content",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte("index"))
+				})
+				r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+				return r
+			},
+			method:           "GET",
+			expectedSpanName: "[GET] /missing/1",
+		},
+		{
+			name: "method not allowed",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.Get("/missing/{id}", func(w http.ResponseWriter, r *http.Request) {})
+				return r
+			},
+			method:           "POST",
+			expectedSpanName: "[POST] method not allowed",
+		},
+		{
+			name: "custom unmatched route patterns",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing(WithUnmatchedRoutePatterns("unknown", "unsupported")))
+				r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+				return r
+			},
+			method:           "GET",
+			expectedSpanName: "[GET] unknown",
+		},
+		{
+			name: "middleware wrapping the router",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+				return OpencensusTracing()(r)
+			},
+			method:           "GET",
+			expectedSpanName: "[GET] /missing/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			req, _ := http.NewRequest(tt.method, "/missing/1", nil)
+			tt.handler().ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+			for _, name := range []string{"http.route", "id"} {
+				if _, ok := spanData.Attributes[name]; ok {
+					t.Fatalf("Expected no span attribute of name '%s' for a request matching no route", name)
+				}
+			}
+		})
+	}
+}
+
+func TestOpencensusTracing_span_name_of_mounted_subrouter(t *testing.T) {
+	tests := []struct {
+		name             string
+		handler          func() http.Handler
+		path             string
+		expectedSpanName string
+	}{
+		{
+			name: "middleware of the mounted subrouter",
+			handler: func() http.Handler {
+				sub := chi.NewRouter()
+				sub.Use(OpencensusTracing())
+				sub.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+				r := chi.NewRouter()
+				r.Mount("/api", sub)
+				return r
+			},
+			path:             "/api/users/42",
+			expectedSpanName: "[GET] /api/users/{id}",
+		},
+		{
+			name: "middleware of the nested subrouter",
+			handler: func() http.Handler {
+				users := chi.NewRouter()
+				users.Use(OpencensusTracing())
+				users.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+				api := chi.NewRouter()
+				api.Mount("/users", users)
+
+				r := chi.NewRouter()
+				r.Mount("/api", api)
+				return r
+			},
+			path:             "/api/users/42",
+			expectedSpanName: "[GET] /api/users/{id}",
+		},
+		{
+			name: "middleware of the root router",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.Route("/api", func(r chi.Router) {
+					r.Route("/users", func(r chi.Router) {
+						r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {})
+					})
+				})
+				return r
+			},
+			path:             "/api/users/42",
+			expectedSpanName: "[GET] /api/users/{id}",
+		},
+		{
+			name: "index route of the mounted subrouter",
+			handler: func() http.Handler {
+				sub := chi.NewRouter()
+				sub.Use(OpencensusTracing())
+				sub.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+				r := chi.NewRouter()
+				r.Mount("/api", sub)
+				return r
+			},
+			path:             "/api/",
+			expectedSpanName: "[GET] /api/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			tt.handler().ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opencensus.io/trace"
+)
+
+// RouteConfig overrides the configuration of the middleware for the requests of a route, see WithRouteOverride
+type RouteConfig struct {
+	// PayloadCapture tells whether the request and response payloads of the route are captured
+	PayloadCapture bool
+	// Sampler samples the requests of the route, the sampler of the middleware is kept if nil
+	Sampler trace.Sampler
+}
+
+type routeOverride struct {
+	pattern string
+	rc      RouteConfig
+	cfg     *config
+}
+
+// WithRouteOverride overrides the payload capture and the sampling of the requests matching the pattern,
+// so a single middleware covers the whole router, e.g.
+// WithRouteOverride("/uploads/*", RouteConfig{PayloadCapture: false, Sampler: trace.NeverSample()}).
+// The pattern follows the path.Match syntax of WithIgnoredPaths, the first matching override wins.
+// A debug request, see WithDebugHeader, is sampled regardless of the override.
+func WithRouteOverride(pattern string, rc RouteConfig) Option {
+	return func(c *config) {
+		c.routeOverrides = append(c.routeOverrides, &routeOverride{pattern: pattern, rc: rc})
+	}
+}
+
+// resolveRouteOverrides derives the configuration of every route override, once all the options are applied
+func (c *config) resolveRouteOverrides() {
+	for _, o := range c.routeOverrides {
+		oc := *c
+		oc.routeOverrides = nil
+		oc.noPayloadCapture = !o.rc.PayloadCapture
+		if sampler := o.rc.Sampler; sampler != nil {
+			oc.samplerFunc = func(*http.Request) trace.Sampler {
+				return sampler
+			}
+		}
+		o.cfg = &oc
+	}
+}
+
+// forRoute returns the configuration of the first route override matching the request, if any
+func (c *config) forRoute(r *http.Request) *config {
+	if len(c.routeOverrides) == 0 {
+		return c
+	}
+	routePattern := resolveRoutePattern(r)
+	for _, o := range c.routeOverrides {
+		if matchPath(o.pattern, routePattern) || matchPath(o.pattern, r.URL.Path) {
+			return o.cfg
+		}
+	}
+	return c
+}
+
+// payloadCaptureLimit is the number of payload bytes captured by the request and response decorators
+func (c *config) payloadCaptureLimit() int {
+	if c.noPayloadCapture {
+		return 0
+	}
+	return c.payloadSizeLimit
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_route_override(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithRouteOverride("/uploads/*", RouteConfig{PayloadCapture: false, Sampler: trace.NeverSample()}),
+		WithRouteOverride("/files/{id}", RouteConfig{PayloadCapture: false}),
+	))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte("response"))
+	}
+	r.Post("/uploads/*", handler)
+	r.Post("/files/{id}", handler)
+	r.Post("/users", handler)
+
+	for _, path := range []string{"/uploads/avatar.png", "/files/42", "/users"} {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString("request"))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	tests := []struct {
+		spanName                string
+		expectedRequestPayload  interface{}
+		expectedResponsePayload interface{}
+	}{
+		{spanName: "[POST] /files/{id}", expectedRequestPayload: nil, expectedResponsePayload: nil},
+		{spanName: "[POST] /users", expectedRequestPayload: "request", expectedResponsePayload: "response"},
+	}
+	for i, tt := range tests {
+		spanData := exporter.collected[i]
+		if spanData.Name != tt.spanName {
+			t.Fatalf("Expected to collect a span of name '%s', while the actual name was '%s'", tt.spanName, spanData.Name)
+		}
+		if spanData.Attributes[spanRequestPayloadAttributeKey] != tt.expectedRequestPayload {
+			t.Fatalf(
+				"Expected the request payload of span '%s' to be '%v', while it was '%v'",
+				tt.spanName, tt.expectedRequestPayload, spanData.Attributes[spanRequestPayloadAttributeKey],
+			)
+		}
+		if spanData.Attributes[spanResponsePayloadAttributeKey] != tt.expectedResponsePayload {
+			t.Fatalf(
+				"Expected the response payload of span '%s' to be '%v', while it was '%v'",
+				tt.spanName, tt.expectedResponsePayload, spanData.Attributes[spanResponsePayloadAttributeKey],
+			)
+		}
+	}
+}
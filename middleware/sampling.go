@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// minAdaptiveSamplingRequests is the number of requests of a route in a window below which its error rate is not trusted
+const minAdaptiveSamplingRequests = 10
+
+// RateLimitedSampler returns a sampler function, see WithSamplerFunc, sampling at most perSecond requests
+// per second of every route. Every route pattern has its own token bucket, bursting up to perSecond requests.
+func RateLimitedSampler(perSecond float64) func(r *http.Request) trace.Sampler {
+	l := &rateLimiter{
+		perSecond: perSecond,
+		buckets:   make(map[string]*tokenBucket),
+		now:       time.Now,
+	}
+	return l.sampler
+}
+
+type rateLimiter struct {
+	perSecond float64
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (l *rateLimiter) sampler(r *http.Request) trace.Sampler {
+	routePattern := resolveRoutePattern(r)
+	return func(trace.SamplingParameters) trace.SamplingDecision {
+		return trace.SamplingDecision{Sample: l.take(routePattern)}
+	}
+}
+
+// take takes a token of the bucket of the route, telling whether there was one left
+func (l *rateLimiter) take(routePattern string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[routePattern]
+	if !ok {
+		b = &tokenBucket{tokens: l.perSecond, last: now}
+		l.buckets[routePattern] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.perSecond
+	if b.tokens > l.perSecond {
+		b.tokens = l.perSecond
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// AdaptiveSampler samples the requests of every route at a base probability, boosted while the rate
+// of the route requests failing with a 5xx status reaches a threshold, see WithAdaptiveSampler
+type AdaptiveSampler struct {
+	base      trace.Sampler
+	boosted   trace.Sampler
+	threshold float64
+	window    time.Duration
+	mu        sync.Mutex
+	routes    map[string]*routeErrorRate
+	now       func() time.Time
+}
+
+type routeErrorRate struct {
+	windowStart time.Time
+	requests    int64
+	errors      int64
+	boosted     bool
+}
+
+// NewAdaptiveSampler creates a sampler sampling the requests with the base probability, or the boosted one
+// while the error rate of their route is at least errorRateThreshold, e.g. 0.05. The error rate is measured
+// over windows of the provided duration, a route is boosted until a window ends below the threshold.
+func NewAdaptiveSampler(base, boosted, errorRateThreshold float64, window time.Duration) *AdaptiveSampler {
+	return &AdaptiveSampler{
+		base:      trace.ProbabilitySampler(base),
+		boosted:   trace.ProbabilitySampler(boosted),
+		threshold: errorRateThreshold,
+		window:    window,
+		routes:    make(map[string]*routeErrorRate),
+		now:       time.Now,
+	}
+}
+
+// WithAdaptiveSampler samples the requests with the adaptive sampler, which observes the status of every traced response
+func WithAdaptiveSampler(s *AdaptiveSampler) Option {
+	return func(c *config) {
+		WithSamplerFunc(s.Sampler)(c)
+		WithSpanEndHook(s.observe)(c)
+	}
+}
+
+// Sampler resolves the sampler of the request, see WithSamplerFunc
+func (s *AdaptiveSampler) Sampler(r *http.Request) trace.Sampler {
+	routePattern := resolveRoutePattern(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rate, ok := s.routes[routePattern]; ok && rate.boosted {
+		return s.boosted
+	}
+	return s.base
+}
+
+func (s *AdaptiveSampler) observe(_ *trace.Span, r *http.Request, statusCode int, _ time.Duration) {
+	routePattern := resolveRoutePattern(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rate, ok := s.routes[routePattern]
+	if !ok {
+		rate = &routeErrorRate{windowStart: now}
+		s.routes[routePattern] = rate
+	}
+	if now.Sub(rate.windowStart) >= s.window {
+		rate.boosted = s.exceeded(rate)
+		rate.windowStart = now
+		rate.requests = 0
+		rate.errors = 0
+	}
+	rate.requests++
+	if statusCode >= http.StatusInternalServerError {
+		rate.errors++
+	}
+	if s.exceeded(rate) {
+		rate.boosted = true
+	}
+}
+
+func (s *AdaptiveSampler) exceeded(rate *routeErrorRate) bool {
+	if rate.requests < minAdaptiveSamplingRequests {
+		return false
+	}
+	return float64(rate.errors)/float64(rate.requests) >= s.threshold
+}
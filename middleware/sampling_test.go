@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_rate_limited_sampler(t *testing.T) {
+	exporter := registerTestExporter()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := &rateLimiter{
+		perSecond: 2,
+		buckets:   make(map[string]*tokenBucket),
+		now:       func() time.Time { return now },
+	}
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithSamplerFunc(limiter.sampler)))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/users/{id}", handler)
+	r.Get("/orders", handler)
+
+	serve := func(path string, times int) {
+		for i := 0; i < times; i++ {
+			req, _ := http.NewRequest("GET", path, nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+
+	serve("/users/1", 5)
+	serve("/orders", 1)
+	now = now.Add(500 * time.Millisecond)
+	serve("/users/2", 5)
+
+	expectedNumberOfSpans := 4
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+}
+
+func TestOpencensusTracing_adaptive_sampler(t *testing.T) {
+	exporter := registerTestExporter()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	sampler := NewAdaptiveSampler(0, 1, 0.5, time.Minute)
+	sampler.now = func() time.Time { return now }
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithAdaptiveSampler(sampler)))
+
+	r.Get("/failing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.Get("/healthy", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string, times int) {
+		for i := 0; i < times; i++ {
+			req, _ := http.NewRequest("GET", path, nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+
+	serve("/healthy", minAdaptiveSamplingRequests)
+	serve("/failing", minAdaptiveSamplingRequests)
+	if len(exporter.collected) != 0 {
+		t.Fatalf("Expected no span to be collected before the error rate is reached, while there were %d", len(exporter.collected))
+	}
+
+	serve("/failing", 2)
+	serve("/healthy", 1)
+	if len(exporter.collected) != 2 {
+		t.Fatalf("Expected 2 spans of the boosted route to be collected, while there were %d", len(exporter.collected))
+	}
+	for _, spanData := range exporter.collected {
+		expectedSpanName := "[GET] /failing"
+		if spanData.Name != expectedSpanName {
+			t.Fatalf("Expected to collect a span of name '%s', while the actual name was '%s'", expectedSpanName, spanData.Name)
+		}
+	}
+
+	// the boost ends with the first window below the threshold
+	now = now.Add(time.Minute)
+	serve("/failing", 1)
+	now = now.Add(time.Minute)
+	serve("/failing", 2)
+	if len(exporter.collected) != 4 {
+		t.Fatalf("Expected the boost to end after a window below the threshold, while there were %d spans collected", len(exporter.collected))
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+// SemanticConventions defines the naming of the span attributes recording the HTTP exchange
+type SemanticConventions int
+
+const (
+	// SemConvOpenCensus names the attributes after the opencensus HTTP conventions, e.g. "http.method"
+	SemConvOpenCensus SemanticConventions = iota
+	// SemConvOTel names the attributes after the OpenTelemetry HTTP semantic conventions, e.g. "http.request.method",
+	// so the spans look native in OpenTelemetry backends
+	SemConvOTel
+)
+
+// attributeKeys are the keys of the span attributes named differently by the semantic conventions
+type attributeKeys struct {
+	method string
+	path   string
+	host   string
+	// hostPort records the port of the host apart from the host if set, otherwise the host includes the port
+	hostPort string
+	// url records the request URL if set
+	url string
+
+	route                 string
+	statusCode            string
+	userAgent             string
+	requestContentLength  string
+	responseContentLength string
+	flavor                string
+	scheme                string
+	peerIP                string
+	peerPort              string
+}
+
+var openCensusAttributeKeys = &attributeKeys{
+	method:                spanMethodAttributeKey,
+	path:                  spanPathAttributeKey,
+	host:                  spanHostAttributeKey,
+	route:                 spanRouteAttributeKey,
+	statusCode:            spanStatusCodeAttributeKey,
+	userAgent:             spanUserAgentAttributeKey,
+	requestContentLength:  spanRequestContentLengthAttributeKey,
+	responseContentLength: spanResponseContentLengthAttributeKey,
+	flavor:                spanFlavorAttributeKey,
+	scheme:                spanSchemeAttributeKey,
+	peerIP:                spanPeerIPAttributeKey,
+	peerPort:              spanPeerPortAttributeKey,
+}
+
+var otelAttributeKeys = &attributeKeys{
+	method:                "http.request.method",
+	path:                  "url.path",
+	host:                  "server.address",
+	hostPort:              "server.port",
+	route:                 "http.route",
+	statusCode:            "http.response.status_code",
+	userAgent:             "user_agent.original",
+	requestContentLength:  "http.request.body.size",
+	responseContentLength: "http.response.body.size",
+	flavor:                "network.protocol.version",
+	scheme:                "url.scheme",
+	peerIP:                "client.address",
+	peerPort:              "client.port",
+}
+
+func (s SemanticConventions) attributeKeys() *attributeKeys {
+	if s == SemConvOTel {
+		return otelAttributeKeys
+	}
+	return openCensusAttributeKeys
+}
+
+// WithSemanticConventions sets the naming of the span attributes recording the HTTP exchange,
+// e.g. SemConvOTel for the spans exported to OpenTelemetry backends. SemConvOpenCensus is used by default.
+// Other attributes, e.g. the payloads or the headers, are named the same regardless.
+func WithSemanticConventions(conventions SemanticConventions) Option {
+	return func(c *config) {
+		c.attributeKeys = conventions.attributeKeys()
+	}
+}
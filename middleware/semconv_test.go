@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_semantic_conventions(t *testing.T) {
+	tests := []struct {
+		name               string
+		conventions        SemanticConventions
+		expectedAttributes map[string]interface{}
+	}{
+		{
+			name:        "opencensus",
+			conventions: SemConvOpenCensus,
+			expectedAttributes: map[string]interface{}{
+				"http.method":                  "GET",
+				"http.path":                    "/users/1",
+				"http.route":                   "/users/{id}",
+				"http.status_code":             int64(http.StatusCreated),
+				"http.user_agent":              "test-agent",
+				"http.response_content_length": int64(2),
+				"http.scheme":                  "http",
+				"peer.ip":                      "192.0.2.1",
+				"http.host":                    "example.com:8080",
+			},
+		},
+		{
+			name:        "opentelemetry",
+			conventions: SemConvOTel,
+			expectedAttributes: map[string]interface{}{
+				"http.request.method":       "GET",
+				"url.path":                  "/users/1",
+				"http.route":                "/users/{id}",
+				"http.response.status_code": int64(http.StatusCreated),
+				"user_agent.original":       "test-agent",
+				"http.response.body.size":   int64(2),
+				"url.scheme":                "http",
+				"client.address":            "192.0.2.1",
+				"server.address":            "example.com",
+				"server.port":               int64(8080),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(WithSemanticConventions(tt.conventions)))
+			r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("OK"))
+			})
+
+			req, _ := http.NewRequest("GET", "/users/1", nil)
+			req.Host = "example.com:8080"
+			req.Header.Set("User-Agent", "test-agent")
+			req.RemoteAddr = "192.0.2.1:1234"
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+			for name, value := range tt.expectedAttributes {
+				if spanData.Attributes[name] != value {
+					t.Fatalf("Expected the span attribute of name '%s' to have value '%v'", name, value)
+				}
+			}
+		})
+	}
+}
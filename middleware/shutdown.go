@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opencensus.io/trace"
+)
+
+var inFlightSpans = &spanTracker{}
+
+// spanTracker counts the request spans started and not yet ended, allowing to wait for all of them to end.
+// The count is updated atomically, the lock guards the idle channel of the waiters only.
+type spanTracker struct {
+	count int64
+	mu    sync.Mutex
+	idle  chan struct{}
+}
+
+func (t *spanTracker) started() {
+	atomic.AddInt64(&t.count, 1)
+}
+
+func (t *spanTracker) ended() {
+	if atomic.AddInt64(&t.count, -1) != 0 {
+		return
+	}
+	t.mu.Lock()
+	if atomic.LoadInt64(&t.count) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+	t.mu.Unlock()
+}
+
+func (t *spanTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if atomic.LoadInt64(&t.count) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// InFlightSpans returns the number of request spans started by the middleware and not yet ended
+func InFlightSpans() int {
+	return int(atomic.LoadInt64(&inFlightSpans.count))
+}
+
+// Shutdown waits for the request spans in flight to end, then flushes the provided exporters
+// buffering spans, e.g. the Jaeger one, so no span is dropped when the service stops, e.g. on SIGTERM.
+// It is meant to be called once the server stops accepting requests, see http.Server.Shutdown.
+// If the context is done first, the exporters are flushed anyway and the context error is returned.
+// A request is in flight until its handler returns, so a connection hijacked by a handler, e.g. a WebSocket
+// served by another goroutine, may still be in use when Shutdown returns. Like http.Server.Shutdown,
+// which does not track hijacked connections either, the service has to close them on its own.
+func Shutdown(ctx context.Context, exporters ...trace.Exporter) error {
+	err := inFlightSpans.wait(ctx)
+	for _, exporter := range exporters {
+		if f, ok := exporter.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+	}
+	return err
+}
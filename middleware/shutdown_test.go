@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+type flushingExporterMock struct {
+	flushed bool
+}
+
+func (e *flushingExporterMock) ExportSpan(*trace.SpanData) {}
+
+func (e *flushingExporterMock) Flush() {
+	e.flushed = true
+}
+
+func TestShutdown(t *testing.T) {
+	_ = registerTestExporter()
+
+	release := make(chan struct{})
+	handling := make(chan struct{})
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		close(handling)
+		<-release
+	})
+
+	served := make(chan struct{})
+	go func() {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		close(served)
+	}()
+	<-handling
+
+	expectedInFlightSpans := 1
+	if InFlightSpans() != expectedInFlightSpans {
+		t.Fatalf("Expected %d span(s) in flight, while there were %d", expectedInFlightSpans, InFlightSpans())
+	}
+
+	exporter := &flushingExporterMock{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := Shutdown(ctx, exporter); err != context.DeadlineExceeded {
+		t.Fatalf("Expected the shutdown to time out while the span is in flight, while it returned: %v", err)
+	}
+	if !exporter.flushed {
+		t.Fatalf("Expected the exporter to be flushed once the shutdown timed out")
+	}
+
+	close(release)
+	<-served
+
+	exporter.flushed = false
+	if err := Shutdown(context.Background(), exporter); err != nil {
+		t.Fatalf("Expected the shutdown to succeed once no span is in flight, while it returned: %v", err)
+	}
+	if !exporter.flushed {
+		t.Fatalf("Expected the exporter to be flushed")
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"runtime"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanSlowAttributeKey          = "http.slow"
+	spanSlowDurationAttributeKey  = "http.slow.duration_ms"
+	spanSlowThresholdAttributeKey = "http.slow.threshold_ms"
+	slowRequestAnnotationMessage  = "Slow request"
+	slowRequestStackDumpMaxSize   = 64 << 10
+	spanSlowStackDumpAttributeKey = "http.slow.goroutines"
+)
+
+// WithSlowRequestThreshold flags the spans of the requests lasting at least the provided duration
+// with the slow attribute and a slow request annotation recording the duration, easing the lookup of latency outliers.
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowRequestThreshold = d
+	}
+}
+
+// WithSlowRequestStackDump attaches the stack dump of all the goroutines, captured as soon as the request
+// reaches the slow request threshold, to the slow request annotation, showing where the request is stuck.
+// The dump is truncated at 64KB. Capturing it stops the world for a while, so the threshold should be rare to reach.
+// It has no effect unless the threshold is set, see WithSlowRequestThreshold.
+func WithSlowRequestStackDump() Option {
+	return func(c *config) {
+		c.slowRequestStackDump = true
+	}
+}
+
+// startSlowRequestTimer schedules the capture of the stack dump of the request reaching the slow request threshold
+func (s *serverSpan) startSlowRequestTimer() {
+	if s.cfg.slowRequestThreshold <= 0 || !s.cfg.slowRequestStackDump {
+		return
+	}
+	s.slowTimer = time.AfterFunc(s.cfg.slowRequestThreshold, func() {
+		buf := make([]byte, slowRequestStackDumpMaxSize)
+		s.slowStack.Store(string(buf[:runtime.Stack(buf, true)]))
+	})
+}
+
+// stopSlowRequestTimer cancels the capture of the stack dump of a request ended before the slow request threshold
+func (s *serverSpan) stopSlowRequestTimer() {
+	if s.slowTimer != nil {
+		s.slowTimer.Stop()
+	}
+}
+
+// flagSlowRequest marks the span of a request of the duration reaching the slow request threshold
+func (s *serverSpan) flagSlowRequest(duration time.Duration) {
+	if s.cfg.slowRequestThreshold <= 0 || duration < s.cfg.slowRequestThreshold {
+		return
+	}
+
+	s.span.AddAttributes(trace.BoolAttribute(spanSlowAttributeKey, true))
+	attrs := []trace.Attribute{
+		trace.Float64Attribute(spanSlowDurationAttributeKey, durationMillis(duration)),
+		trace.Float64Attribute(spanSlowThresholdAttributeKey, durationMillis(s.cfg.slowRequestThreshold)),
+	}
+	if stack, ok := s.slowStack.Load().(string); ok {
+		attrs = append(attrs, trace.StringAttribute(spanSlowStackDumpAttributeKey, stack))
+	}
+	s.span.Annotate(attrs, slowRequestAnnotationMessage)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_slow_request(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		delay             time.Duration
+		expectedSlow      bool
+		expectedStackDump bool
+	}{
+		{
+			name:  "fast request",
+			opts:  []Option{WithSlowRequestThreshold(time.Hour)},
+			delay: 0,
+		},
+		{
+			name:         "slow request",
+			opts:         []Option{WithSlowRequestThreshold(time.Millisecond)},
+			delay:        5 * time.Millisecond,
+			expectedSlow: true,
+		},
+		{
+			name:              "slow request with stack dump",
+			opts:              []Option{WithSlowRequestThreshold(time.Millisecond), WithSlowRequestStackDump()},
+			delay:             50 * time.Millisecond,
+			expectedSlow:      true,
+			expectedStackDump: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(tt.opts...))
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.delay)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			expectedAttributeName := "http.slow"
+			if _, ok := spanData.Attributes[expectedAttributeName]; ok != tt.expectedSlow {
+				t.Fatalf("Expected the presence of the span attribute of name '%s' to be %t", expectedAttributeName, tt.expectedSlow)
+			}
+
+			var slow *trace.Annotation
+			for i, a := range spanData.Annotations {
+				if a.Message == "Slow request" {
+					slow = &spanData.Annotations[i]
+				}
+			}
+			if (slow != nil) != tt.expectedSlow {
+				t.Fatalf("Expected the presence of the slow request annotation to be %t", tt.expectedSlow)
+			}
+			if slow == nil {
+				return
+			}
+
+			stack, ok := slow.Attributes["http.slow.goroutines"].(string)
+			if ok != tt.expectedStackDump {
+				t.Fatalf("Expected the presence of the stack dump to be %t", tt.expectedStackDump)
+			}
+			if ok && !strings.Contains(stack, "goroutine") {
+				t.Fatalf("Expected the stack dump to list the goroutines, while it was '%s'", stack)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go.opencensus.io/trace"
+)
+
+// SpanFromRequest returns the request span started by the middleware, or nil if there is none
+func SpanFromRequest(r *http.Request) *trace.Span {
+	return spanFromContext(r.Context())
+}
+
+// TraceIDFromContext returns the hex-encoded trace ID of the span of the context, e.g. the request span,
+// or an empty string if there is none. It allows correlating logs and responses with the trace,
+// e.g. by setting it as the X-Request-Id response header, see also WithTraceIDResponseHeader.
+func TraceIDFromContext(ctx context.Context) string {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	return span.SpanContext().TraceID.String()
+}
+
+// SetSpanName renames the span of the context, e.g. the request span, once the handler knows more about the request,
+// e.g. the GraphQL operation or the job type. The name of the request span takes precedence over the name resolved
+// from the route when the span is ended. It is a no-op if the context carries no span.
+func SetSpanName(ctx context.Context, name string) {
+	span := spanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.SetName(name)
+
+	if state := requestStateFromContext(ctx); state != nil {
+		state.name = name
+	}
+}
+
+// AddAttributes adds the provided attributes to the span of the context, e.g. the request span.
+// It is a no-op if the context carries no span.
+func AddAttributes(ctx context.Context, attrs ...trace.Attribute) {
+	span := spanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.AddAttributes(attrs...)
+}
+
+// StartSpan starts a child span of the span of the context, e.g. the request span,
+// for tracing internal phases of the request handling, like database calls or cache lookups.
+// The returned context carries the child span, which is ended by calling the returned func.
+func StartSpan(ctx context.Context, name string) (context.Context, func()) {
+	ctx, span := trace.StartSpan(ctx, name)
+	return ctx, span.End
+}
+
+// AddEvent adds an event of the provided name and attributes to the span of the context, e.g. the request span,
+// as a span annotation, e.g. AddEvent(ctx, "cache.miss", map[string]interface{}{"key": key}).
+// Strings, booleans, integers and floats are recorded as they are, other values are formatted with fmt.Sprint.
+// It is a no-op if the context carries no span.
+func AddEvent(ctx context.Context, name string, attributes map[string]interface{}) {
+	span := spanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]trace.Attribute, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, attributeOf(key, attributes[key]))
+	}
+	span.Annotate(attrs, name)
+}
+
+func attributeOf(key string, value interface{}) trace.Attribute {
+	switch v := value.(type) {
+	case string:
+		return trace.StringAttribute(key, v)
+	case bool:
+		return trace.BoolAttribute(key, v)
+	case int:
+		return trace.Int64Attribute(key, int64(v))
+	case int8:
+		return trace.Int64Attribute(key, int64(v))
+	case int16:
+		return trace.Int64Attribute(key, int64(v))
+	case int32:
+		return trace.Int64Attribute(key, int64(v))
+	case int64:
+		return trace.Int64Attribute(key, v)
+	case uint8:
+		return trace.Int64Attribute(key, int64(v))
+	case uint16:
+		return trace.Int64Attribute(key, int64(v))
+	case uint32:
+		return trace.Int64Attribute(key, int64(v))
+	case float32:
+		return trace.Float64Attribute(key, float64(v))
+	case float64:
+		return trace.Float64Attribute(key, v)
+	case fmt.Stringer:
+		return trace.StringAttribute(key, v.String())
+	default:
+		return trace.StringAttribute(key, fmt.Sprint(v))
+	}
+}
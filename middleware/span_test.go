@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestSpanFromRequest_and_AddAttributes(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		if SpanFromRequest(r) == nil {
+			t.Fatal("Expected the request to carry the span")
+		}
+		AddAttributes(r.Context(), trace.StringAttribute("user_id", "42"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedParameterName := "user_id"
+	expectedParameterAttribute := "42"
+	if spanData.Attributes[expectedParameterName] != expectedParameterAttribute {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedParameterName, expectedParameterAttribute)
+	}
+}
+
+func TestAddAttributes_no_span(t *testing.T) {
+	AddAttributes(context.Background(), trace.StringAttribute("user_id", "42"))
+}
+
+func TestStartSpan_child_of_request_span(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, closeSpan := StartSpan(r.Context(), "db call")
+		closeSpan()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	childSpanData := exporter.collected[0]
+	requestSpanData := exporter.collected[1]
+
+	expectedSpanName := "db call"
+	if childSpanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			childSpanData.Name,
+		)
+	}
+
+	if childSpanData.ParentSpanID != requestSpanData.SpanID {
+		t.Fatal("Expected the request span to be the parent of the child span")
+	}
+}
+
+func TestAddEvent(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		AddEvent(r.Context(), "cache.miss", map[string]interface{}{
+			"key":     "user:42",
+			"hit":     false,
+			"size":    3,
+			"latency": 1.5,
+			"other":   []string{"a"},
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	annotations := exporter.collected[0].Annotations
+	if len(annotations) != 1 || annotations[0].Message != "cache.miss" {
+		t.Fatal("Expected the span to have the annotation 'cache.miss'")
+	}
+
+	expectedAttributes := map[string]interface{}{
+		"key":     "user:42",
+		"hit":     false,
+		"size":    int64(3),
+		"latency": 1.5,
+		"other":   "[a]",
+	}
+	for name, value := range expectedAttributes {
+		if annotations[0].Attributes[name] != value {
+			t.Fatalf("Expected the annotation attribute of name '%s' to have value '%v'", name, value)
+		}
+	}
+}
+
+func TestAddEvent_no_span(t *testing.T) {
+	AddEvent(context.Background(), "cache.miss", nil)
+}
+
+func TestSetSpanName(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+	r.Post("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		SetSpanName(r.Context(), "job.resize_image")
+	})
+
+	req, _ := http.NewRequest("POST", "/jobs", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	expectedSpanName := "job.resize_image"
+	if spanData.Name != expectedSpanName {
+		t.Fatalf("Expected the span name to be '%s', while it was '%s'", expectedSpanName, spanData.Name)
+	}
+
+	expectedAttributeName := "http.route"
+	expectedAttributeValue := "/jobs"
+	if spanData.Attributes[expectedAttributeName] != expectedAttributeValue {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", expectedAttributeName, expectedAttributeValue)
+	}
+}
+
+func TestSetSpanName_no_span(t *testing.T) {
+	SetSpanName(context.Background(), "name")
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SpanNameMode defines how the request spans are named by default, see WithSpanNameMode
+type SpanNameMode int
+
+const (
+	// RoutePattern names the spans after the request method and the chi route pattern, e.g. "[GET] /users/{id}"
+	RoutePattern SpanNameMode = iota
+	// RawPath names the spans after the request method and the URL path, e.g. "[GET] /users/42".
+	// It results in a span name per URL, so it suits internal tools of few distinct URLs only.
+	RawPath
+	// HostAndPattern names the spans after the request method, the host and the chi route pattern,
+	// e.g. "[GET] api.example.com/users/{id}", telling apart the same routes served for many hosts
+	HostAndPattern
+)
+
+// WithSpanNameMode sets how the request spans are named, without writing a span name formatter.
+// RoutePattern is used by default. The option overrides the formatter set with WithSpanNameFormatter and vice versa,
+// whichever comes last wins.
+func WithSpanNameMode(mode SpanNameMode) Option {
+	return func(c *config) {
+		switch mode {
+		case RawPath:
+			c.spanNameFormatter = rawPathSpanNameFormatter
+		case HostAndPattern:
+			c.spanNameFormatter = hostAndPatternSpanNameFormatter
+		default:
+			c.spanNameFormatter = defaultSpanNameFormatter
+		}
+	}
+}
+
+func rawPathSpanNameFormatter(r *http.Request, _ string) string {
+	return "[" + r.Method + "] " + r.URL.Path
+}
+
+func hostAndPatternSpanNameFormatter(r *http.Request, routePattern string) string {
+	if !strings.HasPrefix(routePattern, "/") {
+		// a placeholder of a request matching no route, e.g. NotFoundRoutePattern
+		return "[" + r.Method + "] " + r.Host + " " + routePattern
+	}
+	return "[" + r.Method + "] " + r.Host + routePattern
+}
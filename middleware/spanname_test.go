@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_span_name_mode(t *testing.T) {
+	tests := []struct {
+		name             string
+		mode             SpanNameMode
+		path             string
+		expectedSpanName string
+	}{
+		{
+			name:             "route pattern",
+			mode:             RoutePattern,
+			path:             "/users/42",
+			expectedSpanName: "[GET] /users/{id}",
+		},
+		{
+			name:             "raw path",
+			mode:             RawPath,
+			path:             "/users/42",
+			expectedSpanName: "[GET] /users/42",
+		},
+		{
+			name:             "host and pattern",
+			mode:             HostAndPattern,
+			path:             "/users/42",
+			expectedSpanName: "[GET] api.example.com/users/{id}",
+		},
+		{
+			name:             "host and pattern of a request matching no route",
+			mode:             HostAndPattern,
+			path:             "/missing",
+			expectedSpanName: "[GET] api.example.com route not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			r := chi.NewRouter()
+			r.Use(OpencensusTracing(WithSpanNameMode(tt.mode)))
+			r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+			req, _ := http.NewRequest("GET", "http://api.example.com"+tt.path, nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+		})
+	}
+}
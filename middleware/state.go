@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type requestStateKey struct{}
+
+// requestState holds the data handlers attach to the request span through the package helpers,
+// to be taken into account when the middleware closes the span
+type requestState struct {
+	span *trace.Span
+	err  error
+	// start is the moment the request span is started, now tells the current time of the clock it is told by
+	start time.Time
+	now   func() time.Time
+	// superseded tells the span is replaced by the span of the route, see OpencensusTracingNamed
+	superseded bool
+	// otelParent is the OpenTelemetry span of the context the OpenTelemetry span of the request is started in
+	otelParent oteltrace.Span
+	// name overrides the span name resolved from the route once the request is handled, see SetSpanName
+	name string
+	// handlerSpan is the child span the handler is traced in, see HandlerSpan
+	handlerSpan *trace.Span
+	// handlerCtxs are the contexts the package helpers are called with, which the middlewares following
+	// the tracing middleware may derive from the request context, e.g. the chi Timeout middleware
+	handlerCtxsMu sync.Mutex
+	handlerCtxs   []context.Context
+}
+
+func contextWithRequestState(ctx context.Context, span *trace.Span, start time.Time, now func() time.Time) (context.Context, *requestState) {
+	state := &requestState{span: span, start: start, now: now}
+	return context.WithValue(ctx, requestStateKey{}, state), state
+}
+
+// requestStateFromContext returns the state of the request span, if it is the span of the context,
+// noting the context as one the request is handled with
+func requestStateFromContext(ctx context.Context) *requestState {
+	state, ok := ctx.Value(requestStateKey{}).(*requestState)
+	if !ok || !state.isRequestSpan(trace.FromContext(ctx)) {
+		return nil
+	}
+	state.handledWith(ctx)
+	return state
+}
+
+// spanFromContext returns the span of the context, or the request span if it is the handler span, see HandlerSpan,
+// so the package helpers called by the handler keep referring to the request span
+func spanFromContext(ctx context.Context) *trace.Span {
+	span := trace.FromContext(ctx)
+	if state, ok := ctx.Value(requestStateKey{}).(*requestState); ok && span != nil && span == state.handlerSpan {
+		return state.span
+	}
+	return span
+}
+
+// isRequestSpan tells whether the span is the request span or the handler span standing for it
+func (s *requestState) isRequestSpan(span *trace.Span) bool {
+	return span == s.span || (span != nil && span == s.handlerSpan)
+}
+
+func (s *requestState) handledWith(ctx context.Context) {
+	s.handlerCtxsMu.Lock()
+	defer s.handlerCtxsMu.Unlock()
+	if n := len(s.handlerCtxs); n > 0 && s.handlerCtxs[n-1] == ctx {
+		return
+	}
+	s.handlerCtxs = append(s.handlerCtxs, ctx)
+}
+
+// contextErr returns the error of the request context or, if the request context is not done, the deadline
+// exceeded by a context the request is handled with. Other errors of these contexts are ignored,
+// as the middlewares deriving them cancel them once the request is handled.
+func (s *requestState) contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.handlerCtxsMu.Lock()
+	defer s.handlerCtxsMu.Unlock()
+	for _, handlerCtx := range s.handlerCtxs {
+		if err := handlerCtx.Err(); errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	}
+	return nil
+}
+
+// elapsed returns the time elapsed since the start of the request span
+func (s *requestState) elapsed() time.Duration {
+	return s.now().Sub(s.start)
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// WithStats enables recording the opencensus HTTP server measures of ochttp, i.e. the request count, latency,
+// request and response bytes, for every traced request, sampled or not. Measures are tagged
+// with the chi route pattern, the request method and the response status code, see DefaultServerViews.
+func WithStats() Option {
+	return func(c *config) {
+		c.stats = true
+	}
+}
+
+// MetricsAndTracing returns the OpencensusTracing middleware recording the server stats along with the spans,
+// so the tracing and the metrics share the chi route patterns, see WithStats
+func MetricsAndTracing(opts ...Option) func(next http.Handler) http.Handler {
+	return OpencensusTracing(append([]Option{WithStats()}, opts...)...)
+}
+
+// DefaultServerViews returns the views of the HTTP server measures recorded by the middleware,
+// broken down by the chi route pattern, the request method and the response status code.
+// The views still need to be registered with view.Register for the data to be collected.
+func DefaultServerViews() []*view.View {
+	keys := []tag.Key{ochttp.KeyServerRoute, ochttp.Method, ochttp.StatusCode}
+	return []*view.View{
+		{
+			Name:        "opencensus.io/http/server/request_count_by_route",
+			Description: "Count of HTTP requests by route",
+			TagKeys:     keys,
+			Measure:     ochttp.ServerRequestCount,
+			Aggregation: view.Count(),
+		},
+		{
+			Name:        "opencensus.io/http/server/latency_by_route",
+			Description: "Latency distribution of HTTP requests by route",
+			TagKeys:     keys,
+			Measure:     ochttp.ServerLatency,
+			Aggregation: ochttp.DefaultLatencyDistribution,
+		},
+		{
+			Name:        "opencensus.io/http/server/request_bytes_by_route",
+			Description: "Size distribution of HTTP request bodies by route",
+			TagKeys:     keys,
+			Measure:     ochttp.ServerRequestBytes,
+			Aggregation: ochttp.DefaultSizeDistribution,
+		},
+		{
+			Name:        "opencensus.io/http/server/response_bytes_by_route",
+			Description: "Size distribution of HTTP response bodies by route",
+			TagKeys:     keys,
+			Measure:     ochttp.ServerResponseBytes,
+			Aggregation: ochttp.DefaultSizeDistribution,
+		},
+	}
+}
+
+func recordServerStats(r *http.Request, statusCode int, requestBytes, responseBytes int64, duration time.Duration) {
+	_, routePattern := chiRoutePattern(r)
+
+	_ = stats.RecordWithTags(
+		r.Context(),
+		[]tag.Mutator{
+			tag.Upsert(ochttp.KeyServerRoute, routePattern),
+			tag.Upsert(ochttp.Method, r.Method),
+			tag.Upsert(ochttp.StatusCode, strconv.Itoa(statusCode)),
+		},
+		ochttp.ServerRequestCount.M(1),
+		ochttp.ServerLatency.M(durationMillis(duration)),
+		ochttp.ServerRequestBytes.M(requestBytes),
+		ochttp.ServerResponseBytes.M(responseBytes),
+	)
+}
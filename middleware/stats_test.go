@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_stats(t *testing.T) {
+	views := DefaultServerViews()
+	if err := view.Register(views...); err != nil {
+		t.Fatalf("Expected the views to be registered, while it failed with: %s", err)
+	}
+	defer view.Unregister(views...)
+
+	samplers := map[string]trace.Sampler{
+		"/sampled/{id}":     trace.AlwaysSample(),
+		"/not-sampled/{id}": trace.NeverSample(),
+	}
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(
+		WithStats(),
+		WithSamplerFunc(func(r *http.Request) trace.Sampler {
+			return samplers[resolveRoutePattern(r)]
+		}),
+	))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("RESPONSE"))
+	}
+	r.Get("/sampled/{id}", handler)
+	r.Get("/not-sampled/{id}", handler)
+
+	for _, path := range []string{"/sampled/1", "/sampled/2", "/not-sampled/1"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+
+	rows, err := view.RetrieveData("opencensus.io/http/server/request_count_by_route")
+	if err != nil {
+		t.Fatalf("Expected the view data to be retrieved, while it failed with: %s", err)
+	}
+
+	expectedCounts := map[string]int64{
+		"/sampled/{id}":     2,
+		"/not-sampled/{id}": 1,
+	}
+	if len(rows) != len(expectedCounts) {
+		t.Fatalf("Expected %d rows of data, while there were %d", len(expectedCounts), len(rows))
+	}
+	for _, row := range rows {
+		var route, statusCode string
+		for _, tag := range row.Tags {
+			switch tag.Key {
+			case ochttp.KeyServerRoute:
+				route = tag.Value
+			case ochttp.StatusCode:
+				statusCode = tag.Value
+			}
+		}
+
+		count := row.Data.(*view.CountData).Value
+		if count != expectedCounts[route] {
+			t.Fatalf("Expected %d requests of route '%s', while there were %d", expectedCounts[route], route, count)
+		}
+		if statusCode != "200" {
+			t.Fatalf("Expected the requests of route '%s' to be tagged with status code '200'", route)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	statusCodeClientClosedRequest = 499
+)
+
+// DefaultStatusMapper maps the HTTP status code to the span status following the canonical ochttp mapping,
+// e.g. 404 to NotFound, 401 to Unauthenticated or 429 to ResourceExhausted.
+// Status codes below 400 are mapped to OK, unmapped 5xx status codes to Internal
+// and any other unmapped status code to Unknown.
+func DefaultStatusMapper(statusCode int) trace.Status {
+	if statusCode < 400 {
+		return trace.Status{
+			Code:    trace.StatusCodeOK,
+			Message: "OK",
+		}
+	}
+
+	code := int32(trace.StatusCodeUnknown)
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = trace.StatusCodeInvalidArgument
+	case http.StatusUnauthorized:
+		code = trace.StatusCodeUnauthenticated
+	case http.StatusForbidden:
+		code = trace.StatusCodePermissionDenied
+	case http.StatusNotFound:
+		code = trace.StatusCodeNotFound
+	case http.StatusConflict:
+		code = trace.StatusCodeAlreadyExists
+	case http.StatusTooManyRequests:
+		code = trace.StatusCodeResourceExhausted
+	case statusCodeClientClosedRequest:
+		code = trace.StatusCodeCancelled
+	case http.StatusNotImplemented:
+		code = trace.StatusCodeUnimplemented
+	case http.StatusServiceUnavailable:
+		code = trace.StatusCodeUnavailable
+	case http.StatusGatewayTimeout:
+		code = trace.StatusCodeDeadlineExceeded
+	default:
+		if statusCode >= 500 {
+			code = trace.StatusCodeInternal
+		}
+	}
+
+	return trace.Status{
+		Code:    code,
+		Message: fmt.Sprintf("Response status code: %d", statusCode),
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestDefaultStatusMapper(t *testing.T) {
+	cases := map[int]int32{
+		http.StatusOK:                  trace.StatusCodeOK,
+		http.StatusFound:               trace.StatusCodeOK,
+		http.StatusBadRequest:          trace.StatusCodeInvalidArgument,
+		http.StatusUnauthorized:        trace.StatusCodeUnauthenticated,
+		http.StatusForbidden:           trace.StatusCodePermissionDenied,
+		http.StatusNotFound:            trace.StatusCodeNotFound,
+		http.StatusTeapot:              trace.StatusCodeUnknown,
+		http.StatusTooManyRequests:     trace.StatusCodeResourceExhausted,
+		http.StatusInternalServerError: trace.StatusCodeInternal,
+		http.StatusBadGateway:          trace.StatusCodeInternal,
+		http.StatusServiceUnavailable:  trace.StatusCodeUnavailable,
+		http.StatusGatewayTimeout:      trace.StatusCodeDeadlineExceeded,
+	}
+
+	for statusCode, expectedCode := range cases {
+		if actualCode := DefaultStatusMapper(statusCode).Code; actualCode != expectedCode {
+			t.Fatalf("Expected status code %d to be mapped to '%d', while it was mapped to '%d'", statusCode, expectedCode, actualCode)
+		}
+	}
+}
+
+func TestOpencensusTracing_status_mapper(t *testing.T) {
+	exporter := registerTestExporter()
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithStatusMapper(func(statusCode int) trace.Status {
+		if statusCode == http.StatusNotFound {
+			return trace.Status{Code: trace.StatusCodeOK}
+		}
+		return DefaultStatusMapper(statusCode)
+	})))
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	if exporter.collected[0].Status.Code != trace.StatusCodeOK {
+		t.Fatalf("Expected the span status to be '%d'", trace.StatusCodeOK)
+	}
+}
+
+func TestOpencensusTracing_error_status_threshold(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing(WithErrorStatusThreshold(http.StatusInternalServerError)))
+
+	r.Get("/client-error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	r.Get("/server-error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	for _, path := range []string{"/client-error", "/server-error"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	clientErrorSpanData := exporter.collected[0]
+
+	if clientErrorSpanData.Status.Code != trace.StatusCodeOK {
+		t.Fatalf("Expected the span status of a client error to be '%d'", trace.StatusCodeOK)
+	}
+
+	expectedStatusCode := int64(http.StatusUnprocessableEntity)
+	if clientErrorSpanData.Attributes["http.status_code"] != expectedStatusCode {
+		t.Fatalf("Expected the span attribute of name 'http.status_code' to have value '%d'", expectedStatusCode)
+	}
+
+	if exporter.collected[1].Status.Code != trace.StatusCodeInternal {
+		t.Fatalf("Expected the span status of a server error to be '%d'", trace.StatusCodeInternal)
+	}
+}
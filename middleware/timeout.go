@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	spanContextCanceledAttributeKey = "context.canceled"
+	spanQueueWaitAttributeKey       = "http.queue_wait_ms"
+	dequeuedAnnotationMessage       = "Request dequeued"
+)
+
+// QueueWait is a middleware annotating the request span with the time the request waited in a queue
+// before being handled, e.g. in the backlog of the chi Throttle middleware, it has to directly follow.
+// The annotation marks the moment the request leaves the queue on the span timeline,
+// the wait is recorded in milliseconds as its http.queue_wait_ms attribute.
+// Requests rejected by Throttle get the ResourceExhausted status of their 429 response status code.
+func QueueWait(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if state := requestStateFromContext(r.Context()); state != nil {
+			wait := state.elapsed()
+			state.span.Annotate(
+				[]trace.Attribute{trace.Float64Attribute(spanQueueWaitAttributeKey, durationMillis(wait))},
+				dequeuedAnnotationMessage,
+			)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.opencensus.io/trace"
+)
+
+func TestOpencensusTracing_timeout(t *testing.T) {
+	tests := []struct {
+		name                  string
+		handler               func() http.Handler
+		expectedStatusCode    int32
+		expectedContextCancel bool
+	}{
+		{
+			name: "middleware following timeout",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(chimiddleware.Timeout(time.Millisecond))
+				r.Use(OpencensusTracing())
+				r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+					<-r.Context().Done()
+				})
+				return r
+			},
+			expectedStatusCode:    trace.StatusCodeDeadlineExceeded,
+			expectedContextCancel: true,
+		},
+		{
+			name: "middleware preceding timeout",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.Use(chimiddleware.Timeout(time.Millisecond))
+				r.With(HandlerSpan).Get("/test", func(w http.ResponseWriter, r *http.Request) {
+					<-r.Context().Done()
+				})
+				return r
+			},
+			expectedStatusCode:    trace.StatusCodeDeadlineExceeded,
+			expectedContextCancel: true,
+		},
+		{
+			name: "middleware preceding timeout not reached",
+			handler: func() http.Handler {
+				r := chi.NewRouter()
+				r.Use(OpencensusTracing())
+				r.Use(chimiddleware.Timeout(time.Minute))
+				r.With(HandlerSpan).Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+				return r
+			},
+			expectedStatusCode: trace.StatusCodeOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			tt.handler().ServeHTTP(httptest.NewRecorder(), req)
+
+			spanData := exporter.collected[len(exporter.collected)-1]
+			if spanData.Name != "[GET] /test" {
+				t.Fatalf("Expected the request span to be exported last, while it was '%s'", spanData.Name)
+			}
+
+			if spanData.Status.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected the span status code to be %d, while it was %d", tt.expectedStatusCode, spanData.Status.Code)
+			}
+
+			expectedAttributeName := "context.canceled"
+			if _, ok := spanData.Attributes[expectedAttributeName]; ok != tt.expectedContextCancel {
+				t.Fatalf("Expected the presence of the span attribute of name '%s' to be %t", expectedAttributeName, tt.expectedContextCancel)
+			}
+		})
+	}
+}
+
+func TestQueueWait(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+	r.Use(chimiddleware.Throttle(1))
+	r.Use(QueueWait)
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	var dequeued *trace.Annotation
+	for i, a := range spanData.Annotations {
+		if a.Message == "Request dequeued" {
+			dequeued = &spanData.Annotations[i]
+		}
+	}
+	if dequeued == nil {
+		t.Fatal("Expected the span to have an annotation of message 'Request dequeued'")
+	}
+
+	expectedAttributeName := "http.queue_wait_ms"
+	if wait, ok := dequeued.Attributes[expectedAttributeName].(float64); !ok || wait < 0 {
+		t.Fatalf("Expected the annotation attribute of name '%s' to be a non-negative duration", expectedAttributeName)
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+const spanResponseTrailerAttributeKeyPrefix = "http.response.trailer."
+
+// WithResponseTrailers records the values of the response trailers of the provided names
+// as span attributes of the "http.response.trailer.<name>" key, e.g. "Grpc-Status" or "Grpc-Message" of gRPC-Web.
+// Both the trailers declared by the Trailer header and the ones set with the http.TrailerPrefix are recorded,
+// following the same rules as WithResponseHeaders. The trailers are passed through to the client regardless.
+func WithResponseTrailers(names ...string) Option {
+	return func(c *config) {
+		c.responseTrailers = append(c.responseTrailers, canonicalHeaderNames(names)...)
+	}
+}
+
+// responseTrailers returns the trailers set by the handler in the response headers, keyed by their canonical names
+func responseTrailers(h http.Header) http.Header {
+	trailers := make(http.Header)
+	for _, declared := range h.Values("Trailer") {
+		for _, name := range strings.Split(declared, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if values, ok := h[name]; ok {
+				trailers[name] = values
+			}
+		}
+	}
+	for key, values := range h {
+		if strings.HasPrefix(key, http.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(strings.TrimPrefix(key, http.TrailerPrefix))] = values
+		}
+	}
+	return trailers
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpencensusTracing_response_trailers(t *testing.T) {
+	exporter := registerTestExporter()
+
+	done := make(chan struct{})
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			close(done)
+		})
+	})
+	r.Use(OpencensusTracing(WithResponseTrailers("grpc-status", "grpc-message", "x-checksum")))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status, X-Checksum")
+		_, _ = w.Write([]byte("RESPONSE"))
+		w.(http.Flusher).Flush()
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "OK")
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/test")
+	if err != nil {
+		t.Fatalf("Expected the request to succeed, while it failed with: %s", err)
+	}
+	_, _ = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	<-done
+
+	expectedTrailers := map[string]string{"Grpc-Status": "0", "Grpc-Message": "OK"}
+	for name, expected := range expectedTrailers {
+		if resp.Trailer.Get(name) != expected {
+			t.Fatalf("Expected the trailer '%s' to be passed through as '%s', while it was '%s'", name, expected, resp.Trailer.Get(name))
+		}
+	}
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	attributes := exporter.collected[0].Attributes
+	expectedAttributes := map[string]interface{}{
+		"http.response.trailer.grpc-status":  "0",
+		"http.response.trailer.grpc-message": "OK",
+	}
+	for key, expected := range expectedAttributes {
+		if attributes[key] != expected {
+			t.Fatalf("Expected the span attribute '%s' to be '%v', while it was '%v'", key, expected, attributes[key])
+		}
+	}
+	if _, ok := attributes["http.response.trailer.x-checksum"]; ok {
+		t.Fatal("Expected the declared trailer which is not set not to be recorded")
+	}
+}
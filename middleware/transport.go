@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptrace"
+
+	"github.com/krzysztofreczek/chi-opencensus-tracing/propagation"
+	"go.opencensus.io/trace"
+)
+
+// Transport implements an http.RoundTripper starting a client span for every outgoing request
+// and injecting its span context, along with the baggage of the request context, to the request headers.
+// It is a client-side counterpart of the OpencensusTracing middleware.
+type Transport struct {
+	// Base is the round tripper used to send the requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+	// Propagators are the formats used to inject the span context, the formats the middleware handling the request
+	// of the context is configured with if empty, or else the formats of propagation.DefaultChain
+	Propagators []propagation.Propagator
+	// SemanticConventions sets the naming of the span attributes, SemConvOpenCensus by default
+	SemanticConventions SemanticConventions
+	// ClientTrace enables annotating the client span with the network events of the request, i.e. the DNS lookup,
+	// the TCP connect, the TLS handshake, the connection reuse and the first response byte, see httptrace.ClientTrace
+	ClientTrace bool
+}
+
+// WrapClient returns a copy of the provided client with its transport wrapped by Transport
+func WrapClient(c *http.Client) *http.Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	wrapped := *c
+	wrapped.Transport = &Transport{Base: c.Transport}
+	return &wrapped
+}
+
+// RoundTrip executes a single HTTP transaction within a client span
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := trace.StartSpan(
+		r.Context(),
+		"["+r.Method+"] "+r.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+	)
+	defer span.End()
+	keys := t.SemanticConventions.attributeKeys()
+	setSpanRequestAttributes(span, r, keys)
+	if retryCount, ok := nextRetryCount(ctx); ok {
+		span.AddAttributes(trace.Int64Attribute(spanRetryCountAttributeKey, retryCount))
+	}
+
+	if t.ClientTrace {
+		ctx = httptrace.WithClientTrace(ctx, newClientTrace(span))
+	}
+
+	// a round tripper must not modify the provided request
+	r = r.Clone(ctx)
+	addSpanMessageSentEvent(span, r)
+	setSpanHeaders(span.SpanContext(), r, outboundPropagator(ctx, t.Propagators))
+	injectBaggage(ctx, r.Header)
+
+	resp, err := t.base().RoundTrip(r)
+	if err != nil {
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeUnavailable,
+			Message: err.Error(),
+		})
+		return nil, err
+	}
+
+	span.AddAttributes(trace.Int64Attribute(keys.statusCode, int64(resp.StatusCode)))
+	span.SetStatus(DefaultStatusMapper(resp.StatusCode))
+	return resp, nil
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
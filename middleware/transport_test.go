@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opencensus.io/trace"
+)
+
+func TestTransport_client_span_is_parent_of_server_span(t *testing.T) {
+	exporter := registerTestExporter()
+
+	r := chi.NewRouter()
+	r.Use(OpencensusTracing())
+
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	client := WrapClient(server.Client())
+
+	req, _ := http.NewRequest("GET", server.URL+"/test", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed, while it failed with: %s", err)
+	}
+	_ = resp.Body.Close()
+
+	if req.Header.Get("X-Opencensus-Span") != "" {
+		t.Fatal("Expected the original request not to be modified")
+	}
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	serverSpanData := exporter.collected[0]
+	clientSpanData := exporter.collected[1]
+
+	expectedSpanName := "[GET] /test"
+	if clientSpanData.Name != expectedSpanName {
+		t.Fatalf(
+			"Expected to collect a span of name '%s', while the actual name was '%s'",
+			expectedSpanName,
+			clientSpanData.Name,
+		)
+	}
+
+	if clientSpanData.SpanKind != trace.SpanKindClient {
+		t.Fatalf("Expected the span kind to be '%d'", trace.SpanKindClient)
+	}
+
+	if serverSpanData.ParentSpanID != clientSpanData.SpanID {
+		t.Fatal("Expected the client span to be the parent of the server span")
+	}
+
+	expectedStatusCode := int64(http.StatusNotFound)
+	if clientSpanData.Attributes["http.status_code"] != expectedStatusCode {
+		t.Fatalf("Expected the span attribute of name 'http.status_code' to have value '%d'", expectedStatusCode)
+	}
+
+	if clientSpanData.Status.Code == trace.StatusCodeOK {
+		t.Fatal("Expected the span status not to be OK")
+	}
+}
+
+func TestTransport_round_trip_error(t *testing.T) {
+	exporter := registerTestExporter()
+
+	transport := &Transport{
+		Base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}),
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost/test", nil)
+	ctx, parent := trace.StartSpan(context.Background(), "parent span")
+	_, err := transport.RoundTrip(req.WithContext(ctx))
+	parent.End()
+
+	if err == nil {
+		t.Fatal("Expected the round trip to fail")
+	}
+
+	expectedNumberOfSpans := 2
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	spanData := exporter.collected[0]
+
+	if spanData.Status.Code != trace.StatusCodeUnavailable {
+		t.Fatalf("Expected the span status to be '%d'", trace.StatusCodeUnavailable)
+	}
+
+	if spanData.ParentSpanID != exporter.collected[1].SpanID {
+		t.Fatal("Expected the client span to be a child of the span from the request context")
+	}
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport_client_trace_annotations(t *testing.T) {
+	exporter := registerTestExporter()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := server.Client()
+	client.Transport = &Transport{Base: client.Transport, ClientTrace: true}
+
+	resp, err := client.Get(server.URL + "/test")
+	if err != nil {
+		t.Fatalf("Expected the request to succeed, while it failed with: %s", err)
+	}
+	_ = resp.Body.Close()
+
+	expectedNumberOfSpans := 1
+	if len(exporter.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(exporter.collected),
+		)
+	}
+
+	annotations := make(map[string]bool)
+	for _, a := range exporter.collected[0].Annotations {
+		annotations[a.Message] = true
+	}
+	for _, message := range []string{
+		"Connect started",
+		"Connect done",
+		"TLS handshake started",
+		"TLS handshake done",
+		"Connection obtained",
+		"Request written",
+		"First response byte received",
+	} {
+		if !annotations[message] {
+			t.Fatalf("Expected the span to have an annotation of message '%s'", message)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// WrapHandler returns the handler traced the same way as by the OpencensusTracing middleware,
+// for plain net/http or routers other than chi, e.g. mux.Handle("/users/", WrapHandler(users, "users")).
+// The request spans are named after the provided name or, if it is empty, after the request method and URL path,
+// as there is no chi route pattern. The span name formatter of the options is overridden by a non-empty name.
+func WrapHandler(h http.Handler, name string, opts ...Option) http.Handler {
+	if name != "" {
+		opts = append(opts[:len(opts):len(opts)], WithSpanNameFormatter(func(*http.Request, string) string {
+			return name
+		}))
+	}
+	return OpencensusTracing(opts...)(h)
+}
+
+// WrapHandlerFunc returns the handler function traced the same way as by the OpencensusTracing middleware,
+// see WrapHandler
+func WrapHandlerFunc(f http.HandlerFunc, name string, opts ...Option) http.Handler {
+	return WrapHandler(f, name, opts...)
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		spanName         string
+		expectedSpanName string
+	}{
+		{
+			name:             "named",
+			spanName:         "users",
+			expectedSpanName: "users",
+		},
+		{
+			name:             "unnamed",
+			expectedSpanName: "[GET] /users/42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := registerTestExporter()
+
+			mux := http.NewServeMux()
+			mux.Handle("/users/", WrapHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}, tt.spanName))
+
+			req, _ := http.NewRequest("GET", "/users/42", nil)
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			expectedNumberOfSpans := 1
+			if len(exporter.collected) != expectedNumberOfSpans {
+				t.Fatalf(
+					"Expected to collect %d span(s), while there were %d span(s) collected",
+					expectedNumberOfSpans,
+					len(exporter.collected),
+				)
+			}
+
+			spanData := exporter.collected[0]
+
+			if spanData.Name != tt.expectedSpanName {
+				t.Fatalf("Expected the span name to be '%s', while it was '%s'", tt.expectedSpanName, spanData.Name)
+			}
+
+			expectedAttributeName := "http.status_code"
+			expectedAttributeValue := int64(http.StatusAccepted)
+			if spanData.Attributes[expectedAttributeName] != expectedAttributeValue {
+				t.Fatalf("Expected the span attribute of name '%s' to have value '%d'", expectedAttributeName, expectedAttributeValue)
+			}
+			if _, ok := spanData.Attributes["http.route"]; ok {
+				t.Fatalf("Expected no route attribute outside of chi")
+			}
+		})
+	}
+}
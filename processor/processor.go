@@ -0,0 +1,112 @@
+// Package processor provides an exporter wrapper processing spans before they reach the actual exporter,
+// e.g. to drop high-cardinality attributes or to suppress noisy spans
+package processor
+
+import (
+	"path"
+	"sort"
+
+	"go.opencensus.io/trace"
+)
+
+// Processor transforms the span data before it is exported.
+// Returning false suppresses the span, so it is not exported at all.
+type Processor func(sd *trace.SpanData) bool
+
+type exporter struct {
+	next       trace.Exporter
+	processors []Processor
+}
+
+// NewExporter wraps the exporter, so every span is passed through the processors, in the order provided,
+// before it is exported. Processors work on a copy of the span data, as the data is shared by all the exporters.
+func NewExporter(next trace.Exporter, processors ...Processor) trace.Exporter {
+	return &exporter{
+		next:       next,
+		processors: processors,
+	}
+}
+
+func (e *exporter) ExportSpan(sd *trace.SpanData) {
+	c := *sd
+	c.Attributes = make(map[string]interface{}, len(sd.Attributes))
+	for k, v := range sd.Attributes {
+		c.Attributes[k] = v
+	}
+
+	for _, p := range e.processors {
+		if !p(&c) {
+			return
+		}
+	}
+	e.next.ExportSpan(&c)
+}
+
+// Flush flushes the wrapped exporter, if it buffers spans
+func (e *exporter) Flush() {
+	if f, ok := e.next.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
+
+// DropAttributes removes the attributes of keys matching any of the patterns,
+// following the path.Match syntax, e.g. "user_id" or "http.request.header.*"
+func DropAttributes(patterns ...string) Processor {
+	return func(sd *trace.SpanData) bool {
+		for key := range sd.Attributes {
+			if matchesAny(patterns, key) {
+				delete(sd.Attributes, key)
+				sd.DroppedAttributeCount++
+			}
+		}
+		return true
+	}
+}
+
+// RenameAttribute moves the value of the attribute to the new key, replacing the attribute of that key if any
+func RenameAttribute(from, to string) Processor {
+	return func(sd *trace.SpanData) bool {
+		if v, ok := sd.Attributes[from]; ok {
+			delete(sd.Attributes, from)
+			sd.Attributes[to] = v
+		}
+		return true
+	}
+}
+
+// LimitAttributes caps the number of attributes of the span, keeping the ones of the lowest keys in lexical order
+func LimitAttributes(n int) Processor {
+	return func(sd *trace.SpanData) bool {
+		if len(sd.Attributes) <= n {
+			return true
+		}
+
+		keys := make([]string, 0, len(sd.Attributes))
+		for key := range sd.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys[n:] {
+			delete(sd.Attributes, key)
+			sd.DroppedAttributeCount++
+		}
+		return true
+	}
+}
+
+// Suppress drops the spans for which the predicate returns true, e.g. successful health checks
+func Suppress(predicate func(sd *trace.SpanData) bool) Processor {
+	return func(sd *trace.SpanData) bool {
+		return !predicate(sd)
+	}
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
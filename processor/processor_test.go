@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+type exporterMock struct {
+	collected []*trace.SpanData
+}
+
+func (e *exporterMock) ExportSpan(sd *trace.SpanData) {
+	e.collected = append(e.collected, sd)
+}
+
+func TestNewExporter(t *testing.T) {
+	mock := &exporterMock{}
+	exporter := NewExporter(
+		mock,
+		DropAttributes("user_id", "http.request.header.*"),
+		RenameAttribute("env", "deployment.environment"),
+		LimitAttributes(2),
+	)
+
+	sd := &trace.SpanData{
+		Name: "span",
+		Attributes: map[string]interface{}{
+			"user_id":                          "42",
+			"http.request.header.x-request-id": "id",
+			"env":                              "prod",
+			"http.method":                      "GET",
+			"http.path":                        "/test",
+		},
+	}
+	exporter.ExportSpan(sd)
+
+	expectedNumberOfSpans := 1
+	if len(mock.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(mock.collected),
+		)
+	}
+
+	exported := mock.collected[0]
+
+	expectedAttributes := map[string]interface{}{
+		"deployment.environment": "prod",
+		"http.method":            "GET",
+	}
+	if len(exported.Attributes) != len(expectedAttributes) {
+		t.Fatalf("Expected the span to have %d attributes, while it had %d", len(expectedAttributes), len(exported.Attributes))
+	}
+	for name, value := range expectedAttributes {
+		if exported.Attributes[name] != value {
+			t.Fatalf("Expected the span attribute of name '%s' to have value '%s'", name, value)
+		}
+	}
+
+	expectedDroppedAttributeCount := 3
+	if exported.DroppedAttributeCount != expectedDroppedAttributeCount {
+		t.Fatalf("Expected %d attributes to be dropped, while it was %d", expectedDroppedAttributeCount, exported.DroppedAttributeCount)
+	}
+
+	if len(sd.Attributes) != 5 {
+		t.Fatal("Expected the original span data not to be modified")
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	mock := &exporterMock{}
+	exporter := NewExporter(mock, Suppress(func(sd *trace.SpanData) bool {
+		return sd.Name == "[GET] /healthz"
+	}))
+
+	exporter.ExportSpan(&trace.SpanData{Name: "[GET] /healthz"})
+	exporter.ExportSpan(&trace.SpanData{Name: "[GET] /users/{id}"})
+
+	expectedNumberOfSpans := 1
+	if len(mock.collected) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(mock.collected),
+		)
+	}
+}
@@ -0,0 +1,144 @@
+package propagation
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	// HeaderNameB3TraceID is the B3 multi header carrying the trace ID
+	HeaderNameB3TraceID = "X-B3-TraceId"
+	// HeaderNameB3SpanID is the B3 multi header carrying the span ID
+	HeaderNameB3SpanID = "X-B3-SpanId"
+	// HeaderNameB3Sampled is the B3 multi header carrying the sampling decision
+	HeaderNameB3Sampled = "X-B3-Sampled"
+	// HeaderNameB3Flags is the B3 multi header carrying the debug flag
+	HeaderNameB3Flags = "X-B3-Flags"
+	// HeaderNameB3Single is the B3 single header
+	HeaderNameB3Single = "b3"
+)
+
+var (
+	canonicalHeaderNameB3TraceID = http.CanonicalHeaderKey(HeaderNameB3TraceID)
+	canonicalHeaderNameB3SpanID  = http.CanonicalHeaderKey(HeaderNameB3SpanID)
+	canonicalHeaderNameB3Sampled = http.CanonicalHeaderKey(HeaderNameB3Sampled)
+	canonicalHeaderNameB3Flags   = http.CanonicalHeaderKey(HeaderNameB3Flags)
+	canonicalHeaderNameB3Single  = http.CanonicalHeaderKey(HeaderNameB3Single)
+)
+
+type b3Propagator struct{}
+
+// B3 returns the propagator of the Zipkin B3 format.
+// Both the single and the multi header variants are extracted, the single one taking precedence,
+// and both are injected.
+func B3() Propagator {
+	return b3Propagator{}
+}
+
+func (b3Propagator) Inject(sc trace.SpanContext, h http.Header) {
+	traceID := hex.EncodeToString(sc.TraceID[:])
+	spanID := hex.EncodeToString(sc.SpanID[:])
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+
+	h[canonicalHeaderNameB3TraceID] = []string{traceID}
+	h[canonicalHeaderNameB3SpanID] = []string{spanID}
+	h[canonicalHeaderNameB3Sampled] = []string{sampled}
+	h[canonicalHeaderNameB3Single] = []string{traceID + "-" + spanID + "-" + sampled}
+}
+
+func (b3Propagator) Extract(h http.Header) (sc trace.SpanContext, ok bool) {
+	if single := headerValue(h, canonicalHeaderNameB3Single); single != "" {
+		return parseB3Single(single)
+	}
+	return parseB3Multi(h)
+}
+
+func parseB3Multi(h http.Header) (sc trace.SpanContext, ok bool) {
+	traceID, ok := parseB3TraceID(headerValue(h, canonicalHeaderNameB3TraceID))
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+	spanID, ok := parseB3SpanID(headerValue(h, canonicalHeaderNameB3SpanID))
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+
+	sampled, _ := parseB3Sampled(headerValue(h, canonicalHeaderNameB3Sampled))
+	if headerValue(h, canonicalHeaderNameB3Flags) == "1" {
+		sampled = true
+	}
+
+	return newSpanContext(traceID, spanID, sampled), true
+}
+
+// parseB3Single parses the single header format:
+// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}, where the last two are optional.
+// A header carrying the sampling state only holds no span context.
+func parseB3Single(v string) (sc trace.SpanContext, ok bool) {
+	parts := strings.Split(v, "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return trace.SpanContext{}, false
+	}
+
+	traceID, ok := parseB3TraceID(parts[0])
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+	spanID, ok := parseB3SpanID(parts[1])
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+
+	var sampled bool
+	if len(parts) > 2 {
+		sampled, ok = parseB3Sampled(parts[2])
+		if !ok {
+			return trace.SpanContext{}, false
+		}
+	}
+	if len(parts) > 3 {
+		if _, ok = parseB3SpanID(parts[3]); !ok {
+			return trace.SpanContext{}, false
+		}
+	}
+
+	return newSpanContext(traceID, spanID, sampled), true
+}
+
+func parseB3TraceID(v string) (tid trace.TraceID, ok bool) {
+	if len(v) != 32 && len(v) != 16 {
+		return trace.TraceID{}, false
+	}
+	// 64-bit trace IDs occupy the lower half of the 128-bit ID
+	if _, err := hex.Decode(tid[len(tid)-len(v)/2:], []byte(v)); err != nil {
+		return trace.TraceID{}, false
+	}
+	return tid, tid != trace.TraceID{}
+}
+
+func parseB3SpanID(v string) (sid trace.SpanID, ok bool) {
+	if len(v) != 16 {
+		return trace.SpanID{}, false
+	}
+	if _, err := hex.Decode(sid[:], []byte(v)); err != nil {
+		return trace.SpanID{}, false
+	}
+	return sid, sid != trace.SpanID{}
+}
+
+func parseB3Sampled(v string) (sampled bool, ok bool) {
+	switch strings.ToLower(v) {
+	case "1", "true", "d":
+		return true, true
+	case "0", "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,43 @@
+package propagation
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestB3_extract_multi_header_64bit_trace_id(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderNameB3TraceID, "48485a3953bb6124")
+	h.Set(HeaderNameB3SpanID, "0020000000000001")
+	h.Set(HeaderNameB3Flags, "1")
+
+	sc, ok := B3().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+
+	expectedTraceID := "000000000000000048485a3953bb6124"
+	if sc.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, sc.TraceID)
+	}
+
+	if !sc.IsSampled() {
+		t.Fatal("Expected the debug flag to mark the span context as sampled")
+	}
+}
+
+func TestParseB3Single_invalid(t *testing.T) {
+	values := []string{
+		"1",
+		"d",
+		"463ac35c9f6413ad-zz",
+		"00000000000000000000000000000000-0020000000000001-1",
+		"463ac35c9f6413ad48485a3953bb6124-0020000000000001-x",
+	}
+
+	for _, v := range values {
+		if _, ok := parseB3Single(v); ok {
+			t.Fatalf("Expected b3 header '%s' to be rejected", v)
+		}
+	}
+}
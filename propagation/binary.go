@@ -0,0 +1,63 @@
+package propagation
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+	ocpropagation "go.opencensus.io/trace/propagation"
+)
+
+const (
+	// HeaderNameBinary is the header carrying the base64 encoded opencensus binary span context
+	HeaderNameBinary = "X-Opencensus-Span"
+	// HeaderNameGRPCTraceBin is the gRPC metadata header carrying the opencensus binary span context,
+	// base64 encoded over HTTP like the values of all the binary gRPC headers
+	HeaderNameGRPCTraceBin = "grpc-trace-bin"
+
+	// binaryMaxSize bounds the size of the binary span context, which takes 29 bytes in its current version
+	binaryMaxSize = 48
+)
+
+var canonicalHeaderNameGRPCTraceBin = http.CanonicalHeaderKey(HeaderNameGRPCTraceBin)
+
+type binaryPropagator struct {
+	header string
+}
+
+// Binary returns the propagator of the base64 encoded opencensus binary format
+func Binary() Propagator {
+	return binaryPropagator{header: HeaderNameBinary}
+}
+
+// GRPCTraceBin returns the propagator of the opencensus binary format carried by the grpc-trace-bin header,
+// as used by the gRPC opencensus plugin, sharing the trace context with gRPC servers and grpc-gateway
+// without conversion. Values are extracted whether their base64 encoding is padded or not, as gRPC allows both.
+func GRPCTraceBin() Propagator {
+	return binaryPropagator{header: canonicalHeaderNameGRPCTraceBin}
+}
+
+func (p binaryPropagator) Extract(h http.Header) (sc trace.SpanContext, ok bool) {
+	b64 := strings.TrimRight(headerValue(h, p.header), "=")
+	if b64 == "" {
+		return trace.SpanContext{}, false
+	}
+
+	var bin [binaryMaxSize]byte
+	if base64.RawStdEncoding.DecodedLen(len(b64)) > len(bin) {
+		return trace.SpanContext{}, false
+	}
+	n, err := base64.RawStdEncoding.Decode(bin[:], []byte(b64))
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	return ocpropagation.FromBinary(bin[:n])
+}
+
+func (p binaryPropagator) Inject(sc trace.SpanContext, h http.Header) {
+	bin := ocpropagation.Binary(sc)
+	b64 := base64.StdEncoding.EncodeToString(bin)
+	h[p.header] = []string{b64}
+}
@@ -0,0 +1,68 @@
+package propagation
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/trace"
+	ocpropagation "go.opencensus.io/trace/propagation"
+)
+
+func TestGRPCTraceBin_extract_unpadded(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+
+	h := http.Header{}
+	h.Set(HeaderNameGRPCTraceBin, base64.RawStdEncoding.EncodeToString(ocpropagation.Binary(sc)))
+
+	extracted, ok := GRPCTraceBin().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+	if extracted.TraceID != sc.TraceID || extracted.SpanID != sc.SpanID || extracted.TraceOptions != sc.TraceOptions {
+		t.Fatalf("Expected to extract '%v', while the actual span context was '%v'", sc, extracted)
+	}
+}
+
+func TestGRPCTraceBin_inject_round_trip(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+
+	h := http.Header{}
+	GRPCTraceBin().Inject(sc, h)
+
+	if h.Get(HeaderNameBinary) != "" {
+		t.Fatalf("Expected no %s header to be injected", HeaderNameBinary)
+	}
+
+	extracted, ok := GRPCTraceBin().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+	if extracted.TraceID != sc.TraceID || extracted.SpanID != sc.SpanID || extracted.TraceOptions != sc.TraceOptions {
+		t.Fatalf("Expected to extract '%v', while the actual span context was '%v'", sc, extracted)
+	}
+}
+
+func TestBinary_extract_invalid(t *testing.T) {
+	values := []string{
+		"not base64!",
+		"AAAA",
+		base64.StdEncoding.EncodeToString(make([]byte, 64)),
+	}
+
+	for _, v := range values {
+		h := http.Header{}
+		h.Set(HeaderNameBinary, v)
+		if _, ok := Binary().Extract(h); ok {
+			t.Fatalf("Expected binary header '%s' to be rejected", v)
+		}
+	}
+}
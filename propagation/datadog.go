@@ -0,0 +1,105 @@
+package propagation
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	// HeaderNameDatadogTraceID is the Datadog header carrying the lower 64 bits of the trace ID as a decimal number
+	HeaderNameDatadogTraceID = "x-datadog-trace-id"
+	// HeaderNameDatadogParentID is the Datadog header carrying the span ID as a decimal number
+	HeaderNameDatadogParentID = "x-datadog-parent-id"
+	// HeaderNameDatadogSamplingPriority is the Datadog header carrying the sampling decision
+	HeaderNameDatadogSamplingPriority = "x-datadog-sampling-priority"
+	// HeaderNameDatadogTags is the Datadog header carrying the trace tags, among them the upper 64 bits of the trace ID
+	HeaderNameDatadogTags = "x-datadog-tags"
+
+	datadogTraceIDHighTag = "_dd.p.tid"
+)
+
+var (
+	canonicalHeaderNameDatadogTraceID          = http.CanonicalHeaderKey(HeaderNameDatadogTraceID)
+	canonicalHeaderNameDatadogParentID         = http.CanonicalHeaderKey(HeaderNameDatadogParentID)
+	canonicalHeaderNameDatadogSamplingPriority = http.CanonicalHeaderKey(HeaderNameDatadogSamplingPriority)
+	canonicalHeaderNameDatadogTags             = http.CanonicalHeaderKey(HeaderNameDatadogTags)
+)
+
+type datadogPropagator struct{}
+
+// Datadog returns the propagator of the Datadog headers format, joining the traces of Datadog-instrumented services.
+// Datadog trace IDs are 64-bit, the upper 64 bits of the 128-bit trace ID are carried by the _dd.p.tid tag.
+// A positive sampling priority samples the span, i.e. the priorities of the sampler and of the user keep.
+func Datadog() Propagator {
+	return datadogPropagator{}
+}
+
+func (datadogPropagator) Inject(sc trace.SpanContext, h http.Header) {
+	samplingPriority := "0"
+	if sc.IsSampled() {
+		samplingPriority = "1"
+	}
+
+	h[canonicalHeaderNameDatadogTraceID] = []string{strconv.FormatUint(binary.BigEndian.Uint64(sc.TraceID[8:]), 10)}
+	h[canonicalHeaderNameDatadogParentID] = []string{strconv.FormatUint(binary.BigEndian.Uint64(sc.SpanID[:]), 10)}
+	h[canonicalHeaderNameDatadogSamplingPriority] = []string{samplingPriority}
+
+	tags := datadogTagsWithout(headerValue(h, canonicalHeaderNameDatadogTags), datadogTraceIDHighTag)
+	if high := binary.BigEndian.Uint64(sc.TraceID[:8]); high != 0 {
+		tags = append(tags, datadogTraceIDHighTag+"="+hex.EncodeToString(sc.TraceID[:8]))
+	}
+	if len(tags) > 0 {
+		h[canonicalHeaderNameDatadogTags] = []string{strings.Join(tags, ",")}
+	} else {
+		delete(h, canonicalHeaderNameDatadogTags)
+	}
+}
+
+func (datadogPropagator) Extract(h http.Header) (sc trace.SpanContext, ok bool) {
+	low, err := strconv.ParseUint(headerValue(h, canonicalHeaderNameDatadogTraceID), 10, 64)
+	if err != nil || low == 0 {
+		return trace.SpanContext{}, false
+	}
+	parentID, err := strconv.ParseUint(headerValue(h, canonicalHeaderNameDatadogParentID), 10, 64)
+	if err != nil || parentID == 0 {
+		return trace.SpanContext{}, false
+	}
+
+	var traceID trace.TraceID
+	binary.BigEndian.PutUint64(traceID[8:], low)
+	for _, tag := range strings.Split(headerValue(h, canonicalHeaderNameDatadogTags), ",") {
+		if v := strings.TrimPrefix(tag, datadogTraceIDHighTag+"="); v != tag && len(v) == 16 {
+			// a malformed tag leaves the 64-bit trace ID
+			var high [8]byte
+			if _, err := hex.Decode(high[:], []byte(v)); err == nil {
+				copy(traceID[:8], high[:])
+			}
+		}
+	}
+
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], parentID)
+
+	samplingPriority, _ := strconv.Atoi(headerValue(h, canonicalHeaderNameDatadogSamplingPriority))
+
+	return newSpanContext(traceID, spanID, samplingPriority > 0), true
+}
+
+// datadogTagsWithout returns the tags of the header value, except the one of the provided key
+func datadogTagsWithout(v, key string) []string {
+	if v == "" {
+		return nil
+	}
+	var tags []string
+	for _, tag := range strings.Split(v, ",") {
+		if !strings.HasPrefix(tag, key+"=") {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
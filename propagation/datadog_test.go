@@ -0,0 +1,96 @@
+package propagation
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestDatadog_extract(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderNameDatadogTraceID, "5208512171318403364")
+	h.Set(HeaderNameDatadogParentID, "9007199254740993")
+	h.Set(HeaderNameDatadogSamplingPriority, "2")
+	h.Set(HeaderNameDatadogTags, "_dd.p.dm=-4,_dd.p.tid=640cfd8d00000000")
+
+	sc, ok := Datadog().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+
+	expectedTraceID := "640cfd8d0000000048485a3953bb6124"
+	if sc.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, sc.TraceID)
+	}
+
+	expectedSpanID := "0020000000000001"
+	if sc.SpanID.String() != expectedSpanID {
+		t.Fatalf("Expected span ID to be '%s', while the actual one was '%s'", expectedSpanID, sc.SpanID)
+	}
+
+	if !sc.IsSampled() {
+		t.Fatal("Expected the user keep priority to mark the span context as sampled")
+	}
+}
+
+func TestDatadog_extract_malformed_trace_id_tag(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderNameDatadogTraceID, "5208512171318403364")
+	h.Set(HeaderNameDatadogParentID, "9007199254740993")
+	h.Set(HeaderNameDatadogTags, "_dd.p.tid=640cfd8dzz000000")
+
+	sc, ok := Datadog().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+
+	expectedTraceID := "000000000000000048485a3953bb6124"
+	if sc.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, sc.TraceID)
+	}
+}
+
+func TestDatadog_extract_invalid(t *testing.T) {
+	headers := []map[string]string{
+		{HeaderNameDatadogParentID: "9007199254740993"},
+		{HeaderNameDatadogTraceID: "5208512171318403364"},
+		{HeaderNameDatadogTraceID: "0", HeaderNameDatadogParentID: "9007199254740993"},
+		{HeaderNameDatadogTraceID: "48485a3953bb6124", HeaderNameDatadogParentID: "9007199254740993"},
+	}
+
+	for _, values := range headers {
+		h := http.Header{}
+		for name, value := range values {
+			h.Set(name, value)
+		}
+		if _, ok := Datadog().Extract(h); ok {
+			t.Fatalf("Expected datadog headers '%v' to be rejected", values)
+		}
+	}
+}
+
+func TestDatadog_inject_round_trip(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+
+	h := http.Header{}
+	h.Set(HeaderNameDatadogTags, "_dd.p.dm=-4,_dd.p.tid=ffffffffffffffff")
+	Datadog().Inject(sc, h)
+
+	expectedTags := "_dd.p.dm=-4,_dd.p.tid=0102030405060708"
+	if h.Get(HeaderNameDatadogTags) != expectedTags {
+		t.Fatalf("Expected the datadog tags to be '%s', while they were '%s'", expectedTags, h.Get(HeaderNameDatadogTags))
+	}
+
+	extracted, ok := Datadog().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+	if extracted.TraceID != sc.TraceID || extracted.SpanID != sc.SpanID || extracted.TraceOptions != sc.TraceOptions {
+		t.Fatalf("Expected to extract '%v', while the actual span context was '%v'", sc, extracted)
+	}
+}
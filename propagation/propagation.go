@@ -0,0 +1,118 @@
+// Package propagation provides span context propagation formats
+// used by the tracing middleware to continue traces across services
+package propagation
+
+import (
+	"net/http"
+
+	"go.opencensus.io/trace"
+)
+
+// Propagator extracts span context from and injects it into HTTP headers
+type Propagator interface {
+	// Extract resolves the span context carried by the headers
+	Extract(h http.Header) (sc trace.SpanContext, ok bool)
+	// Inject writes the span context to the headers
+	Inject(sc trace.SpanContext, h http.Header)
+}
+
+// Chain is an ordered list of propagators.
+// Extraction returns the span context of the first propagator recognizing the headers,
+// while injection writes the span context in all the formats of the chain.
+type Chain []Propagator
+
+// NewChain creates a chain of the provided propagators
+func NewChain(propagators ...Propagator) Chain {
+	return Chain(propagators)
+}
+
+// DefaultChain returns the chain of the default formats:
+// binary header, W3C traceparent and B3. Datadog, X-Ray and grpc-trace-bin must be chained explicitly, see AllFormats.
+func DefaultChain() Chain {
+	return NewChain(Binary(), TraceContext(), B3())
+}
+
+// AllFormats returns the chain of all the supported formats:
+// binary header, W3C traceparent, B3, grpc-trace-bin, Datadog and X-Ray
+func AllFormats() Chain {
+	return NewChain(Binary(), TraceContext(), B3(), GRPCTraceBin(), Datadog(), XRay())
+}
+
+// SpanContextToHeaders returns the headers carrying the span context in all the formats of the provided propagators,
+// or of DefaultChain if none are provided, e.g. to propagate the span context in the headers of a queued message
+func SpanContextToHeaders(sc trace.SpanContext, propagators ...Propagator) http.Header {
+	h := make(http.Header)
+	chainOrDefault(propagators).Inject(sc, h)
+	return h
+}
+
+// HeadersToSpanContext resolves the span context carried by the headers using the first of the provided propagators
+// recognizing them, or of DefaultChain if none are provided, see SpanContextToHeaders
+func HeadersToSpanContext(h http.Header, propagators ...Propagator) (sc trace.SpanContext, ok bool) {
+	return chainOrDefault(propagators).Extract(h)
+}
+
+func chainOrDefault(propagators []Propagator) Chain {
+	if len(propagators) == 0 {
+		return DefaultChain()
+	}
+	return NewChain(propagators...)
+}
+
+// HeaderNames returns the canonical names of the headers of all the supported formats
+func HeaderNames() []string {
+	return []string{
+		http.CanonicalHeaderKey(HeaderNameBinary),
+		canonicalHeaderNameGRPCTraceBin,
+		canonicalHeaderNameTraceParent,
+		canonicalHeaderNameTraceState,
+		canonicalHeaderNameB3TraceID,
+		canonicalHeaderNameB3SpanID,
+		canonicalHeaderNameB3Sampled,
+		canonicalHeaderNameB3Flags,
+		canonicalHeaderNameB3Single,
+		canonicalHeaderNameDatadogTraceID,
+		canonicalHeaderNameDatadogParentID,
+		canonicalHeaderNameDatadogSamplingPriority,
+		canonicalHeaderNameDatadogTags,
+		canonicalHeaderNameXRay,
+	}
+}
+
+// Extract resolves the span context using the first propagator recognizing the headers
+func (c Chain) Extract(h http.Header) (sc trace.SpanContext, ok bool) {
+	for _, p := range c {
+		if sc, ok = p.Extract(h); ok {
+			return sc, true
+		}
+	}
+	return trace.SpanContext{}, false
+}
+
+// Inject writes the span context to the headers using all the propagators of the chain
+func (c Chain) Inject(sc trace.SpanContext, h http.Header) {
+	for _, p := range c {
+		p.Inject(sc, h)
+	}
+}
+
+// headerValue returns the first value of the header of the canonical key,
+// sparing the canonicalization done by http.Header.Get on every lookup
+func headerValue(h http.Header, canonicalKey string) string {
+	if v := h[canonicalKey]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func newSpanContext(traceID trace.TraceID, spanID trace.SpanID, sampled bool) trace.SpanContext {
+	var options trace.TraceOptions
+	if sampled {
+		options = 1
+	}
+	return trace.SpanContext{
+		TraceID:      traceID,
+		SpanID:       spanID,
+		TraceOptions: options,
+	}
+}
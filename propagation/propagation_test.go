@@ -0,0 +1,177 @@
+package propagation
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestChain_extract_first_recognized_format(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderNameTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Set(HeaderNameB3Single, "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1")
+
+	sc, ok := NewChain(Binary(), TraceContext(), B3()).Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+
+	expectedTraceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	if sc.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, sc.TraceID)
+	}
+}
+
+func TestChain_extract_no_recognized_format(t *testing.T) {
+	if _, ok := DefaultChain().Extract(http.Header{}); ok {
+		t.Fatal("Expected no span context to be extracted")
+	}
+}
+
+func TestChain_inject_round_trip(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+
+	h := http.Header{}
+	DefaultChain().Inject(sc, h)
+
+	for _, p := range DefaultChain() {
+		extracted, ok := p.Extract(h)
+		if !ok {
+			t.Fatalf("Expected the span context to be extracted by %T", p)
+		}
+		if extracted.TraceID != sc.TraceID || extracted.SpanID != sc.SpanID || extracted.TraceOptions != sc.TraceOptions {
+			t.Fatalf("Expected %T to extract '%v', while the actual span context was '%v'", p, sc, extracted)
+		}
+	}
+}
+
+func TestSpanContextToHeaders_round_trip_of_all_formats(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+
+	for i, p := range AllFormats() {
+		h := SpanContextToHeaders(sc, p)
+		extracted, ok := HeadersToSpanContext(h, p)
+		if !ok {
+			t.Fatalf("Expected the span context of format %d to be extracted from headers %v", i, h)
+		}
+		if extracted.TraceID != sc.TraceID || extracted.SpanID != sc.SpanID || !extracted.IsSampled() {
+			t.Fatalf("Expected the span context of format %d to round trip, while it was %+v", i, extracted)
+		}
+	}
+}
+
+func TestSpanContextToHeaders_default_formats(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+
+	h := SpanContextToHeaders(sc)
+	for _, name := range []string{HeaderNameBinary, HeaderNameTraceParent, HeaderNameB3TraceID} {
+		if h.Get(name) == "" {
+			t.Fatalf("Expected %s header to be set", name)
+		}
+	}
+	if h.Get(HeaderNameXRay) != "" {
+		t.Fatalf("Expected %s header not to be set", HeaderNameXRay)
+	}
+
+	extracted, ok := HeadersToSpanContext(h)
+	if !ok || extracted.SpanID != sc.SpanID {
+		t.Fatalf("Expected the span context to be extracted, while it was %+v", extracted)
+	}
+}
+
+func TestTraceContext_extract_invalid(t *testing.T) {
+	values := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	}
+
+	for _, v := range values {
+		h := http.Header{}
+		h.Set(HeaderNameTraceParent, v)
+		if _, ok := TraceContext().Extract(h); ok {
+			t.Fatalf("Expected traceparent header '%s' to be rejected", v)
+		}
+	}
+}
+
+func TestTraceContext_tracestate_round_trip(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderNameTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	h.Set(HeaderNameTraceState, "congo=t61rcWkgMzE, rojo=00f067aa0ba902b7")
+
+	sc, ok := TraceContext().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+
+	if sc.IsSampled() {
+		t.Fatal("Expected the span context not to be sampled")
+	}
+
+	out := http.Header{}
+	TraceContext().Inject(sc, out)
+
+	expectedTraceState := "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"
+	if out.Get(HeaderNameTraceState) != expectedTraceState {
+		t.Fatalf("Expected tracestate header to be '%s', while the actual one was '%s'", expectedTraceState, out.Get(HeaderNameTraceState))
+	}
+}
+
+func BenchmarkDefaultChain(b *testing.B) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:       trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceOptions: 1,
+	}
+	chain := DefaultChain()
+
+	b.Run("extract_none", func(b *testing.B) {
+		h := http.Header{}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = chain.Extract(h)
+		}
+	})
+
+	propagators := map[string]Propagator{
+		"binary":       Binary(),
+		"tracecontext": TraceContext(),
+		"b3":           B3(),
+	}
+	for name, p := range propagators {
+		h := http.Header{}
+		p.Inject(sc, h)
+
+		b.Run("extract_"+name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = chain.Extract(h)
+			}
+		})
+	}
+
+	b.Run("inject", func(b *testing.B) {
+		h := http.Header{}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			chain.Inject(sc, h)
+		}
+	})
+}
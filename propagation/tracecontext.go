@@ -0,0 +1,145 @@
+package propagation
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+	"go.opencensus.io/trace/tracestate"
+)
+
+const (
+	// HeaderNameTraceParent is the W3C Trace Context header carrying the span context
+	HeaderNameTraceParent = "traceparent"
+	// HeaderNameTraceState is the W3C Trace Context header carrying vendor specific trace data
+	HeaderNameTraceState = "tracestate"
+
+	traceContextVersion = "00"
+)
+
+var (
+	canonicalHeaderNameTraceParent = http.CanonicalHeaderKey(HeaderNameTraceParent)
+	canonicalHeaderNameTraceState  = http.CanonicalHeaderKey(HeaderNameTraceState)
+)
+
+type traceContextPropagator struct{}
+
+// TraceContext returns the propagator of the W3C Trace Context format
+func TraceContext() Propagator {
+	return traceContextPropagator{}
+}
+
+func (traceContextPropagator) Extract(h http.Header) (sc trace.SpanContext, ok bool) {
+	traceParent := headerValue(h, canonicalHeaderNameTraceParent)
+	if traceParent == "" {
+		return trace.SpanContext{}, false
+	}
+
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 {
+		return trace.SpanContext{}, false
+	}
+
+	version := parts[0]
+	if len(version) != 2 || version == "ff" {
+		return trace.SpanContext{}, false
+	}
+	if _, err := hex.DecodeString(version); err != nil {
+		return trace.SpanContext{}, false
+	}
+	if version == traceContextVersion && len(parts) != 4 {
+		return trace.SpanContext{}, false
+	}
+
+	if len(parts[1]) != 32 {
+		return trace.SpanContext{}, false
+	}
+	var traceID trace.TraceID
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == (trace.TraceID{}) {
+		return trace.SpanContext{}, false
+	}
+
+	if len(parts[2]) != 16 {
+		return trace.SpanContext{}, false
+	}
+	var spanID trace.SpanID
+	if _, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || spanID == (trace.SpanID{}) {
+		return trace.SpanContext{}, false
+	}
+
+	if len(parts[3]) != 2 {
+		return trace.SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	sc = newSpanContext(traceID, spanID, flags[0]&1 == 1)
+	sc.Tracestate = parseTraceState(h[canonicalHeaderNameTraceState])
+	return sc, true
+}
+
+func (traceContextPropagator) Inject(sc trace.SpanContext, h http.Header) {
+	h[canonicalHeaderNameTraceParent] = []string{TraceParent(sc)}
+
+	if sc.Tracestate == nil {
+		return
+	}
+	entries := sc.Tracestate.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	pairs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		pairs = append(pairs, e.Key+"="+e.Value)
+	}
+	h[canonicalHeaderNameTraceState] = []string{strings.Join(pairs, ",")}
+}
+
+// TraceParent formats the span context as the value of the W3C traceparent header,
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func TraceParent(sc trace.SpanContext) string {
+	// version-traceid-spanid-flags
+	var buf [2 + 1 + 32 + 1 + 16 + 1 + 2]byte
+	copy(buf[:2], traceContextVersion)
+	buf[2] = '-'
+	hex.Encode(buf[3:35], sc.TraceID[:])
+	buf[35] = '-'
+	hex.Encode(buf[36:52], sc.SpanID[:])
+	buf[52] = '-'
+	buf[53] = '0'
+	buf[54] = '0'
+	if sc.IsSampled() {
+		buf[54] = '1'
+	}
+	return string(buf[:])
+}
+
+// parseTraceState resolves the tracestate entries, dropping the whole state when any of them is malformed
+func parseTraceState(values []string) *tracestate.Tracestate {
+	var entries []tracestate.Entry
+	for _, v := range values {
+		for _, pair := range strings.Split(v, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil
+			}
+			entries = append(entries, tracestate.Entry{Key: kv[0], Value: kv[1]})
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ts, err := tracestate.New(nil, entries...)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
@@ -0,0 +1,92 @@
+package propagation
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	// HeaderNameXRay is the AWS X-Ray header carrying the trace ID, the parent span ID and the sampling decision,
+	// e.g. "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	HeaderNameXRay = "X-Amzn-Trace-Id"
+
+	xrayRootKey       = "Root"
+	xrayParentKey     = "Parent"
+	xraySampledKey    = "Sampled"
+	xrayTraceIDPrefix = "1-"
+)
+
+var canonicalHeaderNameXRay = http.CanonicalHeaderKey(HeaderNameXRay)
+
+type xrayPropagator struct{}
+
+// XRay returns the propagator of the AWS X-Ray header format, joining the traces started by AWS load balancers
+// and X-Ray instrumented services. The X-Ray trace ID, i.e. the epoch time followed by a random number,
+// maps to the 128-bit trace ID. A header of no parent, as added by the load balancers, is not extracted.
+func XRay() Propagator {
+	return xrayPropagator{}
+}
+
+func (xrayPropagator) Inject(sc trace.SpanContext, h http.Header) {
+	traceID := hex.EncodeToString(sc.TraceID[:])
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+
+	h[canonicalHeaderNameXRay] = []string{
+		xrayRootKey + "=" + xrayTraceIDPrefix + traceID[:8] + "-" + traceID[8:] +
+			";" + xrayParentKey + "=" + hex.EncodeToString(sc.SpanID[:]) +
+			";" + xraySampledKey + "=" + sampled,
+	}
+}
+
+func (xrayPropagator) Extract(h http.Header) (sc trace.SpanContext, ok bool) {
+	v := headerValue(h, canonicalHeaderNameXRay)
+	if v == "" {
+		return trace.SpanContext{}, false
+	}
+
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	var hasRoot, hasParent, sampled bool
+	for _, part := range strings.Split(v, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case xrayRootKey:
+			if traceID, ok = parseXRayTraceID(kv[1]); !ok {
+				return trace.SpanContext{}, false
+			}
+			hasRoot = true
+		case xrayParentKey:
+			if spanID, ok = parseB3SpanID(kv[1]); !ok {
+				return trace.SpanContext{}, false
+			}
+			hasParent = true
+		case xraySampledKey:
+			sampled = kv[1] == "1"
+		}
+	}
+	if !hasRoot || !hasParent {
+		return trace.SpanContext{}, false
+	}
+
+	return newSpanContext(traceID, spanID, sampled), true
+}
+
+// parseXRayTraceID parses the trace ID of the format 1-{8 hex digits of epoch}-{24 hex digits of random number}
+func parseXRayTraceID(v string) (tid trace.TraceID, ok bool) {
+	if len(v) != 35 || !strings.HasPrefix(v, xrayTraceIDPrefix) || v[10] != '-' {
+		return trace.TraceID{}, false
+	}
+	if _, err := hex.Decode(tid[:], []byte(v[2:10]+v[11:])); err != nil {
+		return trace.TraceID{}, false
+	}
+	return tid, tid != trace.TraceID{}
+}
@@ -0,0 +1,76 @@
+package propagation
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestXRay_extract(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderNameXRay, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+
+	sc, ok := XRay().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+
+	expectedTraceID := "5759e988bd862e3fe1be46a994272793"
+	if sc.TraceID.String() != expectedTraceID {
+		t.Fatalf("Expected trace ID to be '%s', while the actual one was '%s'", expectedTraceID, sc.TraceID)
+	}
+
+	expectedSpanID := "53995c3f42cd8ad8"
+	if sc.SpanID.String() != expectedSpanID {
+		t.Fatalf("Expected span ID to be '%s', while the actual one was '%s'", expectedSpanID, sc.SpanID)
+	}
+
+	if !sc.IsSampled() {
+		t.Fatal("Expected the span context to be sampled")
+	}
+}
+
+func TestXRay_extract_invalid(t *testing.T) {
+	values := []string{
+		"Parent=53995c3f42cd8ad8;Sampled=1",
+		"Root=5759e988-bd862e3fe1be46a994272793",
+		"Root=1-5759e988bd862e3fe1be46a994272793",
+		"Root=1-5759e988-bd862e3fe1be46a99427279z",
+		"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f",
+		"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=0000000000000000",
+		"Self=1-67891234-12456789abcdef012345678;Root=1-67891233-abcdef012345678912345678",
+	}
+
+	for _, v := range values {
+		h := http.Header{}
+		h.Set(HeaderNameXRay, v)
+		if _, ok := XRay().Extract(h); ok {
+			t.Fatalf("Expected x-ray header '%s' to be rejected", v)
+		}
+	}
+}
+
+func TestXRay_inject_round_trip(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:       trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceOptions: 1,
+	}
+
+	h := http.Header{}
+	XRay().Inject(sc, h)
+
+	expectedHeader := "Root=1-01020304-05060708090a0b0c0d0e0f10;Parent=0102030405060708;Sampled=1"
+	if h.Get(HeaderNameXRay) != expectedHeader {
+		t.Fatalf("Expected the x-ray header to be '%s', while it was '%s'", expectedHeader, h.Get(HeaderNameXRay))
+	}
+
+	extracted, ok := XRay().Extract(h)
+	if !ok {
+		t.Fatal("Expected the span context to be extracted")
+	}
+	if extracted.TraceID != sc.TraceID || extracted.SpanID != sc.SpanID || extracted.TraceOptions != sc.TraceOptions {
+		t.Fatalf("Expected to extract '%v', while the actual span context was '%v'", sc, extracted)
+	}
+}
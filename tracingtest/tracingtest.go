@@ -0,0 +1,125 @@
+// Package tracingtest provides an in-memory exporter and assertions
+// for testing the tracing setup of the services using the middleware
+package tracingtest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// defaultSamplingProbability is the probability of the opencensus default sampler
+const defaultSamplingProbability = 1e-4
+
+// InMemoryExporter collects the exported spans in memory, in the order they are exported
+type InMemoryExporter struct {
+	mu       sync.Mutex
+	spans    []*trace.SpanData
+	exported chan struct{}
+}
+
+// NewInMemoryExporter creates an exporter collecting the spans in memory, it has to be registered
+// with trace.RegisterExporter, see also Register
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{
+		exported: make(chan struct{}, 1),
+	}
+}
+
+// Register registers a new in-memory exporter and samples all the spans for the duration of the test,
+// the exporter is unregistered and the opencensus default sampler, sampling 1 in 10000 traces, is restored
+// once the test ends
+func Register(t testing.TB) *InMemoryExporter {
+	e := NewInMemoryExporter()
+	trace.RegisterExporter(e)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	t.Cleanup(func() {
+		trace.UnregisterExporter(e)
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(defaultSamplingProbability)})
+	})
+	return e
+}
+
+func (e *InMemoryExporter) ExportSpan(sd *trace.SpanData) {
+	e.mu.Lock()
+	e.spans = append(e.spans, sd)
+	e.mu.Unlock()
+
+	select {
+	case e.exported <- struct{}{}:
+	default:
+	}
+}
+
+// Spans returns the spans exported so far
+func (e *InMemoryExporter) Spans() []*trace.SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*trace.SpanData(nil), e.spans...)
+}
+
+// Span returns the first exported span of the provided name, or nil if there is none
+func (e *InMemoryExporter) Span(name string) *trace.SpanData {
+	for _, sd := range e.Spans() {
+		if sd.Name == name {
+			return sd
+		}
+	}
+	return nil
+}
+
+// Reset drops the spans exported so far
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
+
+// WaitForSpans waits until at least n spans are exported, e.g. the ones ended by other goroutines,
+// returning the spans exported so far, or an error if fewer spans are exported before the timeout
+func (e *InMemoryExporter) WaitForSpans(n int, timeout time.Duration) ([]*trace.SpanData, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		spans := e.Spans()
+		if len(spans) >= n {
+			return spans, nil
+		}
+		select {
+		case <-e.exported:
+		case <-deadline.C:
+			return spans, fmt.Errorf("%d span(s) exported within %s, while %d expected", len(spans), timeout, n)
+		}
+	}
+}
+
+// AssertSpanName fails the test if the span is not of the provided name
+func AssertSpanName(t testing.TB, sd *trace.SpanData, name string) {
+	t.Helper()
+	if sd == nil {
+		t.Fatalf("Expected a span of name '%s', while there was none", name)
+	}
+	if sd.Name != name {
+		t.Fatalf("Expected the span name to be '%s', while it was '%s'", name, sd.Name)
+	}
+}
+
+// AssertAttribute fails the test if the span has no attribute of the provided key and value,
+// the value is of the type it is recorded with, i.e. string, bool, int64 or float64
+func AssertAttribute(t testing.TB, sd *trace.SpanData, key string, value interface{}) {
+	t.Helper()
+	if sd == nil {
+		t.Fatalf("Expected a span of the attribute of name '%s', while there was none", key)
+	}
+	actual, ok := sd.Attributes[key]
+	if !ok {
+		t.Fatalf("Expected the span '%s' to have the attribute of name '%s'", sd.Name, key)
+	}
+	if actual != value {
+		t.Fatalf("Expected the span attribute of name '%s' to have value '%v', while it was '%v'", key, value, actual)
+	}
+}
@@ -0,0 +1,62 @@
+package tracingtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzysztofreczek/chi-opencensus-tracing/middleware"
+	"go.opencensus.io/trace"
+)
+
+func TestInMemoryExporter(t *testing.T) {
+	exporter := Register(t)
+
+	r := chi.NewRouter()
+	r.Use(middleware.OpencensusTracing())
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.Spans()
+	expectedNumberOfSpans := 1
+	if len(spans) != expectedNumberOfSpans {
+		t.Fatalf(
+			"Expected to collect %d span(s), while there were %d span(s) collected",
+			expectedNumberOfSpans,
+			len(spans),
+		)
+	}
+
+	AssertSpanName(t, spans[0], "[GET] /users/{id}")
+	AssertAttribute(t, spans[0], "http.route", "/users/{id}")
+	AssertAttribute(t, spans[0], "http.status_code", int64(http.StatusOK))
+
+	exporter.Reset()
+	if len(exporter.Spans()) != 0 {
+		t.Fatal("Expected no span once the exporter is reset")
+	}
+}
+
+func TestInMemoryExporter_WaitForSpans(t *testing.T) {
+	exporter := Register(t)
+
+	go func() {
+		_, span := trace.StartSpan(context.Background(), "background")
+		span.End()
+	}()
+
+	spans, err := exporter.WaitForSpans(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertSpanName(t, spans[0], "background")
+
+	if _, err := exporter.WaitForSpans(2, 10*time.Millisecond); err == nil {
+		t.Fatal("Expected an error waiting for more spans than exported")
+	}
+}